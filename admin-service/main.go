@@ -991,12 +991,15 @@ func dashboardHandler(c *gin.Context) {
 		pendingStr, _ := rdb.Get(ctx, fmt.Sprintf("dashboard:%v:pending", govID)).Result()
 		inProgressStr, _ := rdb.Get(ctx, fmt.Sprintf("dashboard:%v:in_progress", govID)).Result()
 		resolvedStr, _ := rdb.Get(ctx, fmt.Sprintf("dashboard:%v:resolved", govID)).Result()
+		reopenedStr, _ := rdb.Get(ctx, fmt.Sprintf("dashboard:%v:reopened", govID)).Result()
 		pending, _ := strconv.ParseInt(pendingStr, 10, 64)
 		inProgress, _ := strconv.ParseInt(inProgressStr, 10, 64)
 		resolved, _ := strconv.ParseInt(resolvedStr, 10, 64)
+		reopened, _ := strconv.ParseInt(reopenedStr, 10, 64)
 		result["pending_complaints"] = pending
 		result["in_progress_complaints"] = inProgress
 		result["resolved_complaints"] = resolved
+		result["reopened_complaints"] = reopened
 
 	case "dept_manager":
 		if deptID != nil {
@@ -1006,12 +1009,21 @@ func dashboardHandler(c *gin.Context) {
 			}
 			result["department_id"] = *deptID
 		}
-		pendingStr, _ := rdb.Get(ctx, fmt.Sprintf("dashboard:%v:dept:%v:pending", govID, deptID)).Result()
-		resolvedStr, _ := rdb.Get(ctx, fmt.Sprintf("dashboard:%v:dept:%v:resolved", govID, deptID)).Result()
-		pending, _ := strconv.ParseInt(pendingStr, 10, 64)
-		resolved, _ := strconv.ParseInt(resolvedStr, 10, 64)
+		var pending, inProgress, resolved, reopened int64
+		if deptID != nil {
+			pendingStr, _ := rdb.Get(ctx, fmt.Sprintf("dashboard:%v:dept:%v:pending", govID, *deptID)).Result()
+			inProgressStr, _ := rdb.Get(ctx, fmt.Sprintf("dashboard:%v:dept:%v:in_progress", govID, *deptID)).Result()
+			resolvedStr, _ := rdb.Get(ctx, fmt.Sprintf("dashboard:%v:dept:%v:resolved", govID, *deptID)).Result()
+			reopenedStr, _ := rdb.Get(ctx, fmt.Sprintf("dashboard:%v:dept:%v:reopened", govID, *deptID)).Result()
+			pending, _ = strconv.ParseInt(pendingStr, 10, 64)
+			inProgress, _ = strconv.ParseInt(inProgressStr, 10, 64)
+			resolved, _ = strconv.ParseInt(resolvedStr, 10, 64)
+			reopened, _ = strconv.ParseInt(reopenedStr, 10, 64)
+		}
 		result["pending_complaints"] = pending
+		result["in_progress_complaints"] = inProgress
 		result["resolved_complaints"] = resolved
+		result["reopened_complaints"] = reopened
 	}
 	c.JSON(http.StatusOK, result)
 }
@@ -53,6 +53,7 @@ GROQ_API_KEY = env("GROQ_API_KEY", "")
 GROQ_MODEL = env("GROQ_MODEL", "llama-3.3-70b-versatile")
 GROQ_BASE_URL = env("GROQ_BASE_URL", "https://api.groq.com/openai/v1")
 GRPC_PORT      = env("AI_GRPC_PORT", "50052")
+ANALYSIS_RESULTS_QUEUE = env("COMPLAINT_ANALYSIS_RESULTS_QUEUE", "complaint_analysis_results")
 
 # ── Redis Connection ─────────────────────────────────────────────────────────
 
@@ -161,30 +162,46 @@ def mock_llm_summarize(text: str) -> str:
     return f"[AI Summary] {' '.join(words[:24])}..."
 
 
-def mock_llm_analyze_complaint(data: dict) -> str:
-    """Simulates complaint analysis."""
+SEVERITIES = ("low", "medium", "high", "critical")
+
+
+def mock_llm_analyze_complaint(data: dict) -> dict:
+    """Simulates complaint analysis. Returns a structured triage result."""
     category = data.get("category", "unknown")
     if GROQ_API_KEY:
         try:
-            return groq_chat_completion(
+            raw = groq_chat_completion(
                 system_prompt=(
-                    "You are a civic complaint triage assistant. Return severity, likely department, "
-                    "and suggested SLA in concise text."
+                    "You are a civic complaint triage assistant. Reply with a single JSON object with keys: "
+                    "severity (low|medium|high|critical), suggested_category, suggested_department, "
+                    "confidence (0..1) and summary (one sentence). No prose outside the JSON."
                 ),
                 user_prompt=f"Analyze this complaint payload: {json.dumps(data)}",
                 max_tokens=240,
             )
+            parsed = json.loads(raw[raw.find("{"):raw.rfind("}") + 1])
+            severity = str(parsed.get("severity", "medium")).lower()
+            return {
+                "severity": severity if severity in SEVERITIES else "medium",
+                "suggested_category": str(parsed.get("suggested_category") or category),
+                "suggested_department": str(parsed.get("suggested_department") or ""),
+                "confidence": max(0.0, min(1.0, float(parsed.get("confidence", 0.5)))),
+                "summary": str(parsed.get("summary", "")),
+                "model_version": f"groq:{GROQ_MODEL}",
+            }
         except Exception as e:
             log.error(f"Groq complaint analysis failed, using fallback: {e}")
 
     log.info("🤖 Mock fallback – analyzing complaint...")
     time.sleep(0.2)
-    return (
-        f"[AI Analysis] Category: {category}. "
-        f"Priority recommendation: {'high' if category in ('pothole', 'water', 'sewage') else 'medium'}. "
-        f"Suggested department: Public Works. "
-        f"Estimated resolution time: 48-72 hours."
-    )
+    return {
+        "severity": "high" if category in ("pothole", "water", "sewage") else "medium",
+        "suggested_category": category,
+        "suggested_department": "Public Works",
+        "confidence": 0.3,
+        "summary": f"[AI Analysis] Category: {category}. Estimated resolution time: 48-72 hours.",
+        "model_version": "mock-fallback-v1",
+    }
 
 
 def mock_llm_assistant(query: str) -> str:
@@ -379,8 +396,17 @@ def on_complaint_analysis(ch, method, properties, body):
         log.info(f"🔍 Analysis request for complaint {complaint_id}")
 
         analysis = mock_llm_analyze_complaint(data)
+        analysis["complaint_id"] = complaint_id
         log.info(f"Analysis result: {analysis}")
 
+        # Hand the structured result back to complaint-service
+        ch.basic_publish(
+            exchange="",
+            routing_key=ANALYSIS_RESULTS_QUEUE,
+            body=json.dumps(analysis),
+            properties=pika.BasicProperties(content_type="application/json", delivery_mode=2),
+        )
+
         ch.basic_ack(delivery_tag=method.delivery_tag)
     except Exception as e:
         log.error(f"Analysis handler error: {e}")
@@ -418,6 +444,7 @@ def main():
     # Declare queues
     channel.queue_declare(queue="ai_summarize", durable=True)
     channel.queue_declare(queue="complaint_analysis", durable=True)
+    channel.queue_declare(queue=ANALYSIS_RESULTS_QUEUE, durable=True)
 
     channel.basic_qos(prefetch_count=1)
     channel.basic_consume(queue="ai_summarize", on_message_callback=on_summarize)
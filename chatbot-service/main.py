@@ -611,9 +611,25 @@ async def ingest_documents():
 
         # Ingest complaints
         try:
-            resp = await client.get(f"{COMPLAINT_SERVICE_URL}/complaints")
-            if resp.status_code == 200:
-                complaints = resp.json() if isinstance(resp.json(), list) else resp.json().get("data", [])
+            # complaint-service returns {"complaints": [...], "next_cursor": ...} pages
+            complaints = []
+            cursor = None
+            for _ in range(20):
+                params = {"limit": 200}
+                if cursor:
+                    params["cursor"] = cursor
+                resp = await client.get(f"{COMPLAINT_SERVICE_URL}/complaints", params=params)
+                if resp.status_code != 200:
+                    break
+                page = resp.json()
+                if isinstance(page, list):
+                    complaints.extend(page)
+                    break
+                complaints.extend(page.get("complaints", []))
+                cursor = page.get("next_cursor")
+                if not cursor:
+                    break
+            if complaints:
                 for cmp in complaints:
                     doc_id = f"complaint_{cmp.get('id', cmp.get('ID', 0))}"
                     desc = cmp.get("description", "")
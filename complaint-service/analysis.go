@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ── AI Analysis Results ─────────────────────────────────────────────────────
+//
+// ai-worker consumes complaint_analysis and publishes a structured result on
+// complaint_analysis_results. Every result is kept; the newest is mirrored
+// onto Complaint.AIAnalysis. Staff accept or override a suggestion and the
+// decision is stored next to it for later model evaluation.
+//
+// Results that can never be stored (malformed, unknown complaint) are
+// dropped; anything else, e.g. the database being down, is requeued.
+
+type ComplaintAnalysis struct {
+	ID                    uint      `gorm:"primaryKey" json:"id"`
+	ComplaintID           uint      `gorm:"index;not null" json:"complaint_id"`
+	Severity              string    `json:"severity"` // low | medium | high | critical
+	SuggestedCategory     string    `json:"suggested_category,omitempty"`
+	SuggestedDepartment   string    `json:"suggested_department,omitempty"`
+	SuggestedDepartmentID *uint     `json:"suggested_department_id,omitempty"`
+	Confidence            float64   `json:"confidence"`
+	ModelVersion          string    `gorm:"index" json:"model_version"`
+	Summary               string    `gorm:"type:text" json:"summary,omitempty"`
+	CreatedAt             time.Time `json:"created_at"`
+
+	Decision *AnalysisDecision `gorm:"foreignKey:AnalysisID" json:"decision,omitempty"`
+}
+
+// AnalysisDecision — staff verdict on one analysis (accepted | overridden)
+type AnalysisDecision struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	AnalysisID           uint      `gorm:"uniqueIndex;not null" json:"analysis_id"`
+	ComplaintID          uint      `gorm:"index;not null" json:"complaint_id"`
+	AdminID              uint      `gorm:"not null" json:"admin_id"`
+	Decision             string    `gorm:"not null" json:"decision"`
+	FinalCategory        string    `json:"final_category"`
+	FinalDepartmentID    *uint     `json:"final_department_id,omitempty"`
+	PreviousCategory     string    `json:"previous_category"`
+	PreviousDepartmentID *uint     `json:"previous_department_id,omitempty"`
+	Note                 string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+var (
+	errInvalidAnalysis = errors.New("invalid analysis result")
+	errDecisionExists  = errors.New("analysis already has a decision")
+)
+
+var severities = map[string]bool{"low": true, "medium": true, "high": true, "critical": true}
+
+// storeAnalysis persists one result and mirrors it onto the complaint.
+func storeAnalysis(tx *gorm.DB, analysis *ComplaintAnalysis) error {
+	if err := tx.Create(analysis).Error; err != nil {
+		return err
+	}
+	latest, _ := json.Marshal(analysis)
+	return tx.Model(&Complaint{}).Where("id = ?", analysis.ComplaintID).
+		Update("ai_analysis", string(latest)).Error
+}
+
+func handleAnalysisResult(body []byte) error {
+	var analysis ComplaintAnalysis
+	if err := json.Unmarshal(body, &analysis); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidAnalysis, err)
+	}
+	if analysis.ComplaintID == 0 {
+		return fmt.Errorf("%w: no complaint_id", errInvalidAnalysis)
+	}
+	if !severities[analysis.Severity] {
+		analysis.Severity = "medium"
+	}
+	analysis.ID = 0
+	analysis.Decision = nil
+	return db.Transaction(func(tx *gorm.DB) error {
+		var complaint Complaint
+		if err := tx.Select("id").Limit(1).Find(&complaint, analysis.ComplaintID).Error; err != nil {
+			return err
+		}
+		if complaint.ID == 0 {
+			return fmt.Errorf("%w: complaint %d not found", errInvalidAnalysis, analysis.ComplaintID)
+		}
+		return storeAnalysis(tx, &analysis)
+	})
+}
+
+func startAnalysisConsumer() {
+	queue := env("COMPLAINT_ANALYSIS_RESULTS_QUEUE", "complaint_analysis_results")
+	ch, err := amqpConn.Channel()
+	if err != nil {
+		log.Printf("[complaint-service] Analysis consumer channel failed: %v", err)
+		return
+	}
+	if _, err := ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		log.Printf("[complaint-service] Analysis queue declare failed: %v", err)
+		return
+	}
+	ch.Qos(10, 0, false)
+	msgs, err := ch.Consume(queue, "complaint-service", false, false, false, false, nil)
+	if err != nil {
+		log.Printf("[complaint-service] Analysis consume failed: %v", err)
+		return
+	}
+	log.Printf("[complaint-service] 👂 Listening for AI results on [%s]", queue)
+
+	go func() {
+		for msg := range msgs {
+			err := handleAnalysisResult(msg.Body)
+			switch {
+			case errors.Is(err, errInvalidAnalysis):
+				log.Printf("[complaint-service] Dropping analysis result: %v", err)
+				msg.Nack(false, false)
+				continue
+			case err != nil:
+				// Back off so an outage does not spin on the same messages
+				log.Printf("[complaint-service] Requeueing analysis result: %v", err)
+				time.Sleep(envDuration("ANALYSIS_RETRY_DELAY", 5*time.Second))
+				msg.Nack(false, true)
+				continue
+			}
+			msg.Ack(false)
+		}
+		log.Println("[complaint-service] Analysis consumer stopped (channel closed)")
+	}()
+}
+
+// ── Analysis Handlers ───────────────────────────────────────────────────────
+
+func listAnalysesHandler(c *gin.Context) {
+	var complaint Complaint
+	if err := db.First(&complaint, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "complaint not found"})
+		return
+	}
+	if !canManageComplaint(c, &complaint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "complaint is outside your jurisdiction"})
+		return
+	}
+	var analyses []ComplaintAnalysis
+	db.Preload("Decision").Where("complaint_id = ?", complaint.ID).Order("created_at DESC").Find(&analyses)
+	c.JSON(http.StatusOK, analyses)
+}
+
+// POST /complaints/:id/analyses/:analysis_id/decision
+// "accept" applies the suggestion, "override" applies the staff's category
+// and/or department_id instead. Moving the complaint to another department
+// is a reassignment and, as at /reassign, needs a manager.
+func analysisDecisionHandler(c *gin.Context) {
+	var complaint Complaint
+	if err := db.First(&complaint, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "complaint not found"})
+		return
+	}
+	if !canManageComplaint(c, &complaint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "complaint is outside your jurisdiction"})
+		return
+	}
+	var analysis ComplaintAnalysis
+	if err := db.Preload("Decision").
+		Where("id = ? AND complaint_id = ?", c.Param("analysis_id"), complaint.ID).
+		First(&analysis).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "analysis not found"})
+		return
+	}
+	if analysis.Decision != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": errDecisionExists.Error()})
+		return
+	}
+
+	var body struct {
+		Decision     string `json:"decision" binding:"required,oneof=accept override"`
+		Category     string `json:"category"`
+		DepartmentID *uint  `json:"department_id"`
+		Note         string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	decision := AnalysisDecision{
+		AnalysisID:           analysis.ID,
+		ComplaintID:          complaint.ID,
+		AdminID:              getAdminID(c),
+		PreviousCategory:     complaint.Category,
+		PreviousDepartmentID: complaint.DepartmentID,
+		Note:                 body.Note,
+	}
+	switch body.Decision {
+	case "accept":
+		decision.Decision = "accepted"
+		decision.FinalCategory = analysis.SuggestedCategory
+		decision.FinalDepartmentID = analysis.SuggestedDepartmentID
+		// The model suggests department names; staff may map it to an ID
+		if body.DepartmentID != nil {
+			decision.FinalDepartmentID = body.DepartmentID
+		}
+	case "override":
+		if body.Category == "" && body.DepartmentID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "override needs a category and/or department_id"})
+			return
+		}
+		decision.Decision = "overridden"
+		decision.FinalCategory = body.Category
+		decision.FinalDepartmentID = body.DepartmentID
+	}
+	if decision.FinalCategory == "" {
+		decision.FinalCategory = complaint.Category
+	}
+	if decision.FinalDepartmentID == nil {
+		decision.FinalDepartmentID = complaint.DepartmentID
+	}
+	deptChanged := decision.FinalDepartmentID != nil &&
+		(complaint.DepartmentID == nil || *complaint.DepartmentID != *decision.FinalDepartmentID)
+	if deptChanged && adminHierarchy[getAdminRole(c)] < adminHierarchy["manager"] {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only managers can move a complaint to another department"})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// analysis_id is unique: of two concurrent decisions only one lands
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&decision)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errDecisionExists
+		}
+		if decision.FinalCategory == complaint.Category && !deptChanged {
+			return nil
+		}
+		fromDept, fromCategory := complaint.DepartmentID, complaint.Category
+		complaint.Category = decision.FinalCategory
+		complaint.DepartmentID = decision.FinalDepartmentID
+		// SLA policies match on department and category alike
+		stampSLA(tx, &complaint, time.Now())
+		changes := slaColumns(&complaint)
+		changes["department_id"] = complaint.DepartmentID
+		changes["category"] = complaint.Category
+		if err := updateComplaintVersioned(tx, &complaint, changes); err != nil {
+			return err
+		}
+		return enqueueComplaintEvent(tx, EventComplaintReassigned, &complaint, map[string]interface{}{
+			"from_department_id": fromDept,
+			"from_category":      fromCategory,
+			"admin_id":           getAdminID(c),
+			"analysis_id":        analysis.ID,
+		})
+	})
+	if errors.Is(err, errDecisionExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"complaint": complaint, "decision": decision})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleAnalysisResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		exists  bool
+		wantErr error
+	}{
+		{name: "stored", body: `{"complaint_id":1,"severity":"high","suggested_category":"Roads"}`, exists: true},
+		{name: "unknown severity is kept as medium", body: `{"complaint_id":1,"severity":"apocalyptic"}`, exists: true},
+		{name: "malformed", body: `{"complaint_id":`, wantErr: errInvalidAnalysis},
+		{name: "no complaint", body: `{"severity":"low"}`, wantErr: errInvalidAnalysis},
+		{name: "complaint deleted", body: `{"complaint_id":1,"severity":"low"}`, exists: false, wantErr: errInvalidAnalysis},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			if tt.exists {
+				stub.returns(`FROM "complaints"`, []string{"id"}, []driver.Value{int64(1)})
+			}
+			err := handleAnalysisResult([]byte(tt.body))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			stored := stub.ran(`INSERT INTO "complaint_analyses"`) && stub.ran(`"ai_analysis"`)
+			if stored != (tt.wantErr == nil) {
+				t.Errorf("stored = %v", stored)
+			}
+		})
+	}
+}
+
+func TestAnalysisDecisionHandler(t *testing.T) {
+	manager := map[string]interface{}{"admin_id": float64(3), "admin_role": "manager", "government_id": float64(2)}
+	deptManager := map[string]interface{}{"admin_id": float64(4), "admin_role": "dept_manager", "government_id": float64(2), "department_id": float64(4)}
+	tests := []struct {
+		name       string
+		claims     map[string]interface{}
+		body       string
+		decided    bool  // the analysis already has a decision when loaded
+		inserted   bool  // the decision insert wins
+		updated    int64 // rows the complaint update affects
+		wantCode   int
+		wantUpdate bool
+	}{
+		{name: "accept, nothing to change", claims: manager, body: `{"decision":"accept"}`, inserted: true, updated: 1, wantCode: http.StatusOK},
+		{name: "override category", claims: deptManager, body: `{"decision":"override","category":"Water"}`, inserted: true, updated: 1, wantCode: http.StatusOK, wantUpdate: true},
+		{name: "accept a department move", claims: manager, body: `{"decision":"accept","department_id":9}`, inserted: true, updated: 1, wantCode: http.StatusOK, wantUpdate: true},
+		{name: "department move needs a manager", claims: deptManager, body: `{"decision":"override","department_id":9}`, inserted: true, updated: 1, wantCode: http.StatusForbidden},
+		{name: "override needs a value", claims: manager, body: `{"decision":"override"}`, inserted: true, updated: 1, wantCode: http.StatusBadRequest},
+		{name: "already decided", claims: manager, body: `{"decision":"accept"}`, decided: true, inserted: true, updated: 1, wantCode: http.StatusConflict},
+		{name: "decided concurrently", claims: manager, body: `{"decision":"override","category":"Water"}`, updated: 1, wantCode: http.StatusConflict},
+		{name: "complaint changed meanwhile", claims: manager, body: `{"decision":"override","category":"Water"}`, inserted: true, updated: 0, wantCode: http.StatusConflict, wantUpdate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.execRows = tt.updated
+			stub.returns(`FROM "complaints"`,
+				[]string{"id", "government_id", "department_id", "category", "status", "version"},
+				[]driver.Value{int64(1), int64(2), int64(4), "Roads", StatusPending, int64(1)})
+			stub.returns(`FROM "complaint_analyses"`,
+				[]string{"id", "complaint_id", "suggested_category", "severity"},
+				[]driver.Value{int64(7), int64(1), "Roads", "low"})
+			if tt.decided {
+				stub.returns(`FROM "analysis_decisions"`, []string{"id", "analysis_id", "decision"}, []driver.Value{int64(1), int64(7), "accepted"})
+			}
+			if tt.inserted {
+				stub.returns(`INSERT INTO "analysis_decisions"`, []string{"id"}, []driver.Value{int64(11)})
+			}
+			c, w := newTestContext(http.MethodPost, "/complaints/1/analyses/7/decision", tt.body, tt.claims)
+			c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "analysis_id", Value: "7"}}
+
+			analysisDecisionHandler(c)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if updated := stub.ran("AND version = "); updated != tt.wantUpdate {
+				t.Errorf("version-guarded complaint update = %v, want %v", updated, tt.wantUpdate)
+			}
+		})
+	}
+}
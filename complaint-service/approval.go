@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ── Resolution Approval ─────────────────────────────────────────────────────
+//
+// A government can require manager sign-off before a complaint counts as
+// resolved. When its ResolutionPolicy applies to a complaint:
+//   - the final (100%) ActionTaken must carry an after_image_url uploaded
+//     through POST /complaints/upload/action by staff of the complaint's
+//     government; each photo can sign off one action only
+//   - completion moves the complaint to pending_verification, not resolved
+//   - a manager approves (→ resolved) or rejects with a reason (→ in_progress)
+//   - in_progress → resolved directly is refused
+// The policy can be narrowed to complaints at or above a priority score
+// and/or to a list of categories; either match is enough.
+
+var (
+	errApprovalRequired     = errors.New("this complaint needs manager approval; complete it to send it for verification")
+	errAfterPhoto           = errors.New("the final action needs an after_image_url uploaded via /complaints/upload/action by staff of this government and not used by another action")
+	errAwaitingVerification = errors.New("verification is entered by a 100% action and decided at /resolution/approve or /resolution/reject")
+)
+
+// ResolutionPolicy — one per government
+type ResolutionPolicy struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	GovernmentID    uint      `gorm:"uniqueIndex;not null" json:"government_id"`
+	RequireApproval bool      `gorm:"not null;default:false" json:"require_approval"`
+	MinPriority     *int      `json:"min_priority,omitempty"`                          // priority score threshold
+	Categories      string    `gorm:"type:text;not null;default:''" json:"categories"` // comma list, case-insensitive
+	UpdatedBy       uint      `json:"updated_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// appliesTo reports whether a complaint needs sign-off under this policy.
+func (p *ResolutionPolicy) appliesTo(complaint *Complaint) bool {
+	if !p.RequireApproval {
+		return false
+	}
+	categories := splitIDs(p.Categories)
+	if p.MinPriority == nil && len(categories) == 0 {
+		return true
+	}
+	if p.MinPriority != nil && complaint.PriorityScore() >= *p.MinPriority {
+		return true
+	}
+	for _, category := range categories {
+		if strings.EqualFold(category, complaint.Category) {
+			return true
+		}
+	}
+	return false
+}
+
+func requiresApproval(tx *gorm.DB, complaint *Complaint) bool {
+	var policy ResolutionPolicy
+	tx.Where("government_id = ?", complaint.GovernmentID).Limit(1).Find(&policy)
+	return policy.ID != 0 && policy.appliesTo(complaint)
+}
+
+// claimAfterPhoto ties the action's after_image_url to it. The update is
+// guarded so one photo cannot sign off two actions.
+func claimAfterPhoto(tx *gorm.DB, complaint *Complaint, action *ActionTaken) error {
+	prefix, ok := mediaPrefixFromURL(action.AfterImageURL)
+	if !ok {
+		return errAfterPhoto
+	}
+	res := tx.Model(&MediaObject{}).
+		Where("prefix = ? AND kind = ? AND owner_type = ? AND government_id = ? AND action_id IS NULL",
+			prefix, "actions", "admin", complaint.GovernmentID).
+		Update("action_id", action.ID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errAfterPhoto
+	}
+	return nil
+}
+
+// ── Policy Handlers ──
+
+// GET /complaints/resolution-policy?government_id=
+func getResolutionPolicyHandler(c *gin.Context) {
+	govID := getGovID(c)
+	if getAdminRole(c) == "super_admin" {
+		id, _ := strconv.Atoi(c.Query("government_id"))
+		govID = uint(id)
+	}
+	policy := ResolutionPolicy{GovernmentID: govID}
+	db.Where("government_id = ?", govID).Limit(1).Find(&policy)
+	c.JSON(http.StatusOK, policy)
+}
+
+// PUT /complaints/resolution-policy
+func putResolutionPolicyHandler(c *gin.Context) {
+	var body struct {
+		GovernmentID    uint     `json:"government_id"`
+		RequireApproval bool     `json:"require_approval"`
+		MinPriority     *int     `json:"min_priority"`
+		Categories      []string `json:"categories"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.GovernmentID == 0 {
+		body.GovernmentID = getGovID(c)
+	}
+	if !canManageGovernment(c, body.GovernmentID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "government is outside your jurisdiction"})
+		return
+	}
+	categories := []string{}
+	for _, category := range body.Categories {
+		if category = strings.TrimSpace(category); category != "" {
+			if strings.Contains(category, ",") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "category names cannot contain commas"})
+				return
+			}
+			categories = append(categories, category)
+		}
+	}
+
+	var policy ResolutionPolicy
+	db.Where("government_id = ?", body.GovernmentID).Limit(1).Find(&policy)
+	policy.GovernmentID = body.GovernmentID
+	policy.RequireApproval = body.RequireApproval
+	policy.MinPriority = body.MinPriority
+	policy.Categories = strings.Join(categories, ",")
+	policy.UpdatedBy = getAdminID(c)
+	if err := db.Save(&policy).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// ── Review Handlers ──
+
+// loadPendingVerification fetches the complaint in :id awaiting this
+// manager's sign-off.
+func loadPendingVerification(c *gin.Context) (*Complaint, bool) {
+	var complaint Complaint
+	if err := db.First(&complaint, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "complaint not found"})
+		return nil, false
+	}
+	if !canManageComplaint(c, &complaint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "complaint is outside your jurisdiction"})
+		return nil, false
+	}
+	if complaint.Status != StatusPendingVerification {
+		c.JSON(http.StatusConflict, gin.H{"error": "complaint is not awaiting verification"})
+		return nil, false
+	}
+	return &complaint, true
+}
+
+// POST /complaints/:id/resolution/approve
+func approveResolutionHandler(c *gin.Context) {
+	var body struct {
+		Note string `json:"note"`
+	}
+	c.ShouldBindJSON(&body)
+	complaint, ok := loadPendingVerification(c)
+	if !ok {
+		return
+	}
+	reason := "resolution approved"
+	if note := strings.TrimSpace(body.Note); note != "" {
+		reason += ": " + note
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return transitionStatus(tx, complaint, StatusResolved, actorFromContext(c), reason)
+	})
+	if err != nil {
+		c.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, complaint)
+}
+
+// POST /complaints/:id/resolution/reject — back to in_progress for more work
+func rejectResolutionHandler(c *gin.Context) {
+	var body struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a reason is required"})
+		return
+	}
+	complaint, ok := loadPendingVerification(c)
+	if !ok {
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return transitionStatus(tx, complaint, StatusInProgress, actorFromContext(c), "resolution rejected: "+body.Reason)
+	})
+	if err != nil {
+		c.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, complaint)
+}
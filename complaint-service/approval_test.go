@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestResolutionPolicyAppliesTo(t *testing.T) {
+	priority := func(n int) *int { return &n }
+	roads := &Complaint{Category: "Roads", Upvotes: 12, Downvotes: 1} // score 10
+	tests := []struct {
+		name   string
+		policy ResolutionPolicy
+		want   bool
+	}{
+		{name: "approval off", policy: ResolutionPolicy{MinPriority: priority(0), Categories: "Roads"}, want: false},
+		{name: "no narrowing", policy: ResolutionPolicy{RequireApproval: true}, want: true},
+		{name: "score at threshold", policy: ResolutionPolicy{RequireApproval: true, MinPriority: priority(10)}, want: true},
+		{name: "score below threshold", policy: ResolutionPolicy{RequireApproval: true, MinPriority: priority(11)}, want: false},
+		{name: "category case-insensitive", policy: ResolutionPolicy{RequireApproval: true, Categories: "water, roads"}, want: true},
+		{name: "other categories", policy: ResolutionPolicy{RequireApproval: true, Categories: "Water,Electricity"}, want: false},
+		{name: "either match is enough", policy: ResolutionPolicy{RequireApproval: true, MinPriority: priority(50), Categories: "Roads"}, want: true},
+		{name: "neither matches", policy: ResolutionPolicy{RequireApproval: true, MinPriority: priority(50), Categories: "Water"}, want: false},
+		{name: "blank category list", policy: ResolutionPolicy{RequireApproval: true, Categories: " , "}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.appliesTo(roads); got != tt.want {
+				t.Errorf("appliesTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// requireApproval makes every complaint of government 2 need sign-off.
+func requireApproval(stub *stubDriver) {
+	stub.returns(`FROM "resolution_policies"`,
+		[]string{"id", "government_id", "require_approval", "min_priority", "categories"},
+		[]driver.Value{int64(1), int64(2), true, nil, ""})
+}
+
+func TestTransitionStatusApproval(t *testing.T) {
+	manager := statusActor{ID: 7, Type: "admin"}
+	tests := []struct {
+		name     string
+		from, to string
+		policy   bool
+		wantErr  error
+	}{
+		{name: "resolve without a policy", from: StatusInProgress, to: StatusResolved},
+		{name: "resolve needs approval", from: StatusInProgress, to: StatusResolved, policy: true, wantErr: errApprovalRequired},
+		{name: "send for verification", from: StatusInProgress, to: StatusPendingVerification, policy: true},
+		{name: "approve", from: StatusPendingVerification, to: StatusResolved, policy: true},
+		{name: "send back", from: StatusPendingVerification, to: StatusInProgress, policy: true},
+		{name: "no rejecting while verifying", from: StatusPendingVerification, to: StatusRejected, policy: true, wantErr: errIllegalTransition},
+		{name: "verification only from in_progress", from: StatusPending, to: StatusPendingVerification, policy: true, wantErr: errIllegalTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			if tt.policy {
+				requireApproval(stub)
+			}
+			complaint := Complaint{ID: 1, GovernmentID: 2, Status: tt.from}
+			err := transitionStatus(db, &complaint, tt.to, manager, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			want := tt.to
+			if tt.wantErr != nil {
+				want = tt.from
+			}
+			if complaint.Status != want {
+				t.Errorf("status = %s, want %s", complaint.Status, want)
+			}
+		})
+	}
+}
+
+func TestAdvanceForCompletion(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		percent int
+		policy  bool
+		want    string
+	}{
+		{name: "no progress", from: StatusPending, percent: 0, want: StatusPending},
+		{name: "work started", from: StatusAcknowledged, percent: 40, want: StatusInProgress},
+		{name: "done in one go", from: StatusPending, percent: 100, want: StatusResolved},
+		{name: "done, approval required", from: StatusInProgress, percent: 100, policy: true, want: StatusPendingVerification},
+		{name: "awaiting verification stays", from: StatusPendingVerification, percent: 100, policy: true, want: StatusPendingVerification},
+		{name: "already resolved", from: StatusResolved, percent: 100, want: StatusResolved},
+		{name: "reopened work", from: StatusReopened, percent: 20, want: StatusInProgress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			if tt.policy {
+				requireApproval(stub)
+			}
+			complaint := Complaint{ID: 1, GovernmentID: 2, Status: tt.from}
+			if err := advanceForCompletion(db, &complaint, tt.percent, systemActor); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if complaint.Status != tt.want {
+				t.Errorf("status = %s, want %s", complaint.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimAfterPhoto(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		claimed int64 // rows the guarded update affects
+		wantErr error
+	}{
+		{name: "unclaimed photo", url: mediaURL("actions/2026/10/abc/display.jpg"), claimed: 1},
+		{name: "foreign, used or missing photo", url: mediaURL("actions/2026/10/abc/display.jpg"), claimed: 0, wantErr: errAfterPhoto},
+		{name: "external url", url: "https://example.com/after.jpg", claimed: 1, wantErr: errAfterPhoto},
+		{name: "no url", url: "", claimed: 1, wantErr: errAfterPhoto},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.execRows = tt.claimed
+			action := ActionTaken{ID: 4, AfterImageURL: tt.url}
+			err := claimAfterPhoto(db, &Complaint{ID: 1, GovernmentID: 2}, &action)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ── JWT ─────────────────────────────────────────────────────────────────────
+//
+// Tokens are issued by admin-service with the shared JWT_SECRET:
+//   citizen: user_id, email, role
+//   admin:   admin_id, government_id, department_id, email, role
+// Identity is always taken from the token, never from request bodies.
+
+var jwtSecret = []byte(env("JWT_SECRET", "civic_jwt_secret_2026"))
+
+// Role hierarchy: super_admin > manager > dept_manager
+var adminHierarchy = map[string]int{"super_admin": 3, "manager": 2, "dept_manager": 1}
+
+// setClaims verifies a bearer token and copies its claims onto the context.
+func setClaims(c *gin.Context, tokenStr string) bool {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return false
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if _, ok := claims["admin_id"]; ok {
+		c.Set("admin_id", claims["admin_id"])
+		c.Set("government_id", claims["government_id"])
+		c.Set("admin_role", claims["role"])
+		c.Set("department_id", claims["department_id"])
+	} else {
+		c.Set("user_id", claims["user_id"])
+		c.Set("role", claims["role"])
+	}
+	return true
+}
+
+func authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenStr := c.GetHeader("Authorization")
+		if len(tokenStr) < 8 || tokenStr[:7] != "Bearer " {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
+			return
+		}
+		if !setClaims(c, tokenStr[7:]) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// optionalAuth lets public routes recognise staff and authors. Without a
+// valid token the request simply stays anonymous.
+func optionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokenStr := c.GetHeader("Authorization"); strings.HasPrefix(tokenStr, "Bearer ") {
+			setClaims(c, tokenStr[7:])
+		}
+		c.Next()
+	}
+}
+
+func citizenRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if getUserID(c) == 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "citizen token required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func adminRoleRequired(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if getAdminID(c) == 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not an admin token"})
+			return
+		}
+		callerLevel := adminHierarchy[getAdminRole(c)]
+		for _, r := range roles {
+			if callerLevel >= adminHierarchy[r] {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+	}
+}
+
+func claimUint(c *gin.Context, key string) uint {
+	if v, ok := c.Get(key); ok {
+		switch id := v.(type) {
+		case float64:
+			return uint(id)
+		case uint:
+			return id
+		}
+	}
+	return 0
+}
+
+func getUserID(c *gin.Context) uint  { return claimUint(c, "user_id") }
+func getAdminID(c *gin.Context) uint { return claimUint(c, "admin_id") }
+func getGovID(c *gin.Context) uint   { return claimUint(c, "government_id") }
+
+func getDeptID(c *gin.Context) *uint {
+	if id := claimUint(c, "department_id"); id > 0 {
+		return &id
+	}
+	return nil
+}
+
+func getAdminRole(c *gin.Context) string {
+	role, _ := c.Get("admin_role")
+	roleStr, _ := role.(string)
+	return roleStr
+}
+
+func isAdmin(c *gin.Context) bool {
+	return getAdminID(c) != 0
+}
+
+// canManageComplaint scopes admin operations: super admins see everything,
+// managers their government, dept managers their own department.
+func canManageComplaint(c *gin.Context, complaint *Complaint) bool {
+	switch getAdminRole(c) {
+	case "super_admin":
+		return true
+	case "manager":
+		return complaint.GovernmentID == getGovID(c)
+	case "dept_manager":
+		deptID := getDeptID(c)
+		return complaint.GovernmentID == getGovID(c) &&
+			deptID != nil && complaint.DepartmentID != nil && *complaint.DepartmentID == *deptID
+	}
+	return false
+}
+
+// canManageGovernment scopes government-wide settings (e.g. SLA policies).
+func canManageGovernment(c *gin.Context, govID uint) bool {
+	switch getAdminRole(c) {
+	case "super_admin":
+		return true
+	case "manager":
+		return govID == getGovID(c)
+	}
+	return false
+}
+
+// actorFromContext is the status-history actor for the current caller.
+func actorFromContext(c *gin.Context) statusActor {
+	if id := getAdminID(c); id != 0 {
+		return statusActor{ID: id, Type: "admin"}
+	}
+	return statusActor{ID: getUserID(c), Type: "citizen"}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func signedToken(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	citizen := jwt.MapClaims{"user_id": 5, "email": "a@example.com", "role": "citizen", "exp": time.Now().Add(time.Hour).Unix()}
+	admin := jwt.MapClaims{"admin_id": 3, "government_id": 2, "department_id": 4, "role": "manager", "exp": time.Now().Add(time.Hour).Unix()}
+	tests := []struct {
+		name      string
+		header    string
+		wantCode  int
+		wantUser  uint
+		wantAdmin uint
+		wantRole  string
+	}{
+		{name: "citizen", header: "Bearer " + signedToken(t, jwt.SigningMethodHS256, jwtSecret, citizen), wantCode: http.StatusOK, wantUser: 5},
+		{name: "admin", header: "Bearer " + signedToken(t, jwt.SigningMethodHS256, jwtSecret, admin), wantCode: http.StatusOK, wantAdmin: 3, wantRole: "manager"},
+		{name: "no header", wantCode: http.StatusUnauthorized},
+		{name: "not a bearer token", header: "Basic dXNlcjpwYXNz", wantCode: http.StatusUnauthorized},
+		{name: "garbage", header: "Bearer not.a.jwt", wantCode: http.StatusUnauthorized},
+		{name: "wrong secret", header: "Bearer " + signedToken(t, jwt.SigningMethodHS256, []byte("guess"), citizen), wantCode: http.StatusUnauthorized},
+		{name: "other hmac method", header: "Bearer " + signedToken(t, jwt.SigningMethodHS512, jwtSecret, citizen), wantCode: http.StatusUnauthorized},
+		{name: "unsigned", header: "Bearer " + signedToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, citizen), wantCode: http.StatusUnauthorized},
+		{
+			name:     "expired",
+			header:   "Bearer " + signedToken(t, jwt.SigningMethodHS256, jwtSecret, jwt.MapClaims{"user_id": 5, "exp": time.Now().Add(-time.Minute).Unix()}),
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newTestContext(http.MethodGet, "/complaints/my", "", nil)
+			c.Request.Header.Set("Authorization", tt.header)
+
+			authMiddleware()(c)
+			if !c.IsAborted() {
+				c.Status(http.StatusOK)
+			}
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if getUserID(c) != tt.wantUser || getAdminID(c) != tt.wantAdmin || getAdminRole(c) != tt.wantRole {
+				t.Errorf("user %d admin %d role %q", getUserID(c), getAdminID(c), getAdminRole(c))
+			}
+			if tt.wantAdmin != 0 && (getGovID(c) != 2 || getDeptID(c) == nil || *getDeptID(c) != 4) {
+				t.Errorf("admin scope = government %d department %v", getGovID(c), getDeptID(c))
+			}
+		})
+	}
+}
+
+func TestOptionalAuth(t *testing.T) {
+	for header, wantUser := range map[string]uint{
+		"": 0,
+		"Bearer " + signedToken(t, jwt.SigningMethodHS256, jwtSecret, jwt.MapClaims{"user_id": 5}): 5,
+		"Bearer expired-or-forged": 0,
+	} {
+		c, _ := newTestContext(http.MethodGet, "/complaints/1", "", nil)
+		c.Request.Header.Set("Authorization", header)
+
+		optionalAuth()(c)
+		if c.IsAborted() || getUserID(c) != wantUser {
+			t.Errorf("%q: aborted %v, user %d, want %d", header, c.IsAborted(), getUserID(c), wantUser)
+		}
+	}
+}
+
+func TestAdminRoleRequired(t *testing.T) {
+	tests := []struct {
+		claims map[string]interface{}
+		role   string
+		want   bool
+	}{
+		{map[string]interface{}{"user_id": float64(5)}, "dept_manager", false},
+		{map[string]interface{}{"admin_id": float64(1), "admin_role": "dept_manager"}, "dept_manager", true},
+		{map[string]interface{}{"admin_id": float64(1), "admin_role": "dept_manager"}, "manager", false},
+		{map[string]interface{}{"admin_id": float64(1), "admin_role": "manager"}, "dept_manager", true},
+		{map[string]interface{}{"admin_id": float64(1), "admin_role": "super_admin"}, "manager", true},
+		{map[string]interface{}{"admin_id": float64(1), "admin_role": "intern"}, "dept_manager", false},
+	}
+	for _, tt := range tests {
+		c, _ := newTestContext(http.MethodGet, "/", "", tt.claims)
+		adminRoleRequired(tt.role)(c)
+		if allowed := !c.IsAborted(); allowed != tt.want {
+			t.Errorf("%v for %s: allowed = %v, want %v", tt.claims, tt.role, allowed, tt.want)
+		}
+	}
+}
+
+func TestCanManage(t *testing.T) {
+	dept := func(id uint) *uint { return &id }
+	complaint := &Complaint{GovernmentID: 2, DepartmentID: dept(4)}
+	tests := []struct {
+		name                  string
+		claims                map[string]interface{}
+		complaint, government bool
+	}{
+		{name: "citizen", claims: map[string]interface{}{"user_id": float64(5)}},
+		{name: "super admin", claims: superAdminClaims, complaint: true, government: true},
+		{name: "manager", claims: map[string]interface{}{"admin_id": float64(3), "admin_role": "manager", "government_id": float64(2)}, complaint: true, government: true},
+		{name: "other government", claims: map[string]interface{}{"admin_id": float64(3), "admin_role": "manager", "government_id": float64(9)}},
+		{name: "own department", claims: map[string]interface{}{"admin_id": float64(3), "admin_role": "dept_manager", "government_id": float64(2), "department_id": float64(4)}, complaint: true},
+		{name: "other department", claims: map[string]interface{}{"admin_id": float64(3), "admin_role": "dept_manager", "government_id": float64(2), "department_id": float64(5)}},
+		{name: "no department claim", claims: map[string]interface{}{"admin_id": float64(3), "admin_role": "dept_manager", "government_id": float64(2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(nil)
+			for k, v := range tt.claims {
+				c.Set(k, v)
+			}
+			if got := canManageComplaint(c, complaint); got != tt.complaint {
+				t.Errorf("canManageComplaint() = %v, want %v", got, tt.complaint)
+			}
+			if got := canManageGovernment(c, 2); got != tt.government {
+				t.Errorf("canManageGovernment() = %v, want %v", got, tt.government)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ── Clustering + Heatmap ────────────────────────────────────────────────────
+//
+// Complaints are bucketed into a lon/lat grid whose cell size follows the map
+// zoom, so a cluster covers roughly CLUSTER_CELL_PX screen pixels at that
+// zoom. Both endpoints require bbox + zoom and accept the list filters.
+//   GET /complaints/clusters  → per cell: count, centroid, by_status, by_category
+//   GET /complaints/heatmap   → per finer cell: centroid + weight (PriorityScore)
+
+type ComplaintCluster struct {
+	Count       int            `json:"count"`
+	Latitude    float64        `json:"latitude"`
+	Longitude   float64        `json:"longitude"`
+	ComplaintID *uint          `json:"complaint_id,omitempty"` // set for single-complaint cells
+	ByStatus    map[string]int `json:"by_status"`
+	ByCategory  map[string]int `json:"by_category"`
+}
+
+type HeatmapPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Weight    float64 `json:"weight"`
+}
+
+// gridCellDegrees is the cell edge for a zoom level: a 256px world tile spans
+// 360° at zoom 0 and halves with every level.
+func gridCellDegrees(zoom int, px float64) float64 {
+	return 360.0 / (256.0 * math.Pow(2, float64(zoom))) * px
+}
+
+func clusterCellPx() float64 {
+	px, err := strconv.ParseFloat(env("CLUSTER_CELL_PX", "60"), 64)
+	if err != nil || px <= 0 {
+		return 60
+	}
+	return px
+}
+
+// aggregationQuery validates bbox + zoom and returns the filtered complaints
+// query and the zoom level.
+func aggregationQuery(c *gin.Context) (*gorm.DB, int, error) {
+	if c.Query("bbox") == "" {
+		return nil, 0, fmt.Errorf("bbox is required")
+	}
+	zoom, err := strconv.Atoi(c.Query("zoom"))
+	if err != nil || zoom < 0 || zoom > 22 {
+		return nil, 0, fmt.Errorf("zoom must be an integer between 0 and 22")
+	}
+	query, err := applyComplaintFilters(c, db.Model(&Complaint{}))
+	if err != nil {
+		return nil, 0, err
+	}
+	return query, zoom, nil
+}
+
+// GET /complaints/clusters?bbox=minLng,minLat,maxLng,maxLat&zoom=12
+func complaintClustersHandler(c *gin.Context) {
+	query, zoom, err := aggregationQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cell := gridCellDegrees(zoom, clusterCellPx())
+	cells := query.Select("id, status, COALESCE(category, '') AS category, latitude, longitude, "+
+		"FLOOR(longitude / ?)::bigint AS gx, FLOOR(latitude / ?)::bigint AS gy", cell, cell)
+
+	var rows []struct {
+		Count       int
+		Latitude    float64
+		Longitude   float64
+		ComplaintID *uint
+		ByStatus    string
+		ByCategory  string
+	}
+	err = db.Raw(`
+		WITH f AS (?),
+		statuses AS (
+			SELECT gx, gy, jsonb_object_agg(status, n) AS by_status
+			FROM (SELECT gx, gy, status, COUNT(*) AS n FROM f GROUP BY gx, gy, status) s
+			GROUP BY gx, gy
+		),
+		categories AS (
+			SELECT gx, gy, jsonb_object_agg(category, n) AS by_category
+			FROM (SELECT gx, gy, category, COUNT(*) AS n FROM f GROUP BY gx, gy, category) k
+			GROUP BY gx, gy
+		)
+		SELECT g.count, g.latitude, g.longitude, g.complaint_id,
+			statuses.by_status::text AS by_status, categories.by_category::text AS by_category
+		FROM (
+			SELECT gx, gy, COUNT(*) AS count, AVG(latitude) AS latitude, AVG(longitude) AS longitude,
+				CASE WHEN COUNT(*) = 1 THEN MIN(id) END AS complaint_id
+			FROM f GROUP BY gx, gy
+		) g
+		JOIN statuses USING (gx, gy)
+		JOIN categories USING (gx, gy)
+		ORDER BY g.count DESC`, cells).Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clusters := make([]ComplaintCluster, 0, len(rows))
+	total := 0
+	for _, row := range rows {
+		cluster := ComplaintCluster{
+			Count:       row.Count,
+			Latitude:    row.Latitude,
+			Longitude:   row.Longitude,
+			ComplaintID: row.ComplaintID,
+		}
+		json.Unmarshal([]byte(row.ByStatus), &cluster.ByStatus)
+		json.Unmarshal([]byte(row.ByCategory), &cluster.ByCategory)
+		clusters = append(clusters, cluster)
+		total += row.Count
+	}
+	c.JSON(http.StatusOK, gin.H{"zoom": zoom, "cell_degrees": cell, "total": total, "clusters": clusters})
+}
+
+// GET /complaints/heatmap?bbox=...&zoom=12 — weight per complaint is
+// max(PriorityScore, 0) + 1 so unvoted complaints still show up.
+func complaintHeatmapHandler(c *gin.Context) {
+	query, zoom, err := aggregationQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Finer than clusters: heat layers blur neighbouring points anyway
+	cell := gridCellDegrees(zoom, clusterCellPx()/4)
+	weighted := query.Select("latitude, longitude, GREATEST(upvotes - downvotes * 2, 0) + 1 AS weight, "+
+		"FLOOR(longitude / ?)::bigint AS gx, FLOOR(latitude / ?)::bigint AS gy", cell, cell)
+
+	points := []HeatmapPoint{}
+	err = db.Raw(`
+		SELECT AVG(latitude) AS latitude, AVG(longitude) AS longitude, SUM(weight)::float8 AS weight
+		FROM (?) AS f
+		GROUP BY gx, gy`, weighted).Scan(&points).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	maxWeight := 0.0
+	for _, p := range points {
+		maxWeight = math.Max(maxWeight, p.Weight)
+	}
+	c.JSON(http.StatusOK, gin.H{"zoom": zoom, "cell_degrees": cell, "max_weight": maxWeight, "points": points})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestGridCellDegrees(t *testing.T) {
+	tests := []struct {
+		zoom int
+		px   float64
+		want float64
+	}{
+		{0, 256, 360},
+		{0, 60, 84.375},
+		{1, 256, 180},
+		{10, 256, 360.0 / 1024},
+		{12, 60, 360.0 / (256 * 4096) * 60},
+	}
+	for _, tt := range tests {
+		if got := gridCellDegrees(tt.zoom, tt.px); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("gridCellDegrees(%d, %v) = %v, want %v", tt.zoom, tt.px, got, tt.want)
+		}
+	}
+}
+
+func TestAggregationQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{"?bbox=77.5,12.9,77.7,13.1&zoom=12", false},
+		{"?bbox=77.5,12.9,77.7,13.1&zoom=0", false},
+		{"?zoom=12", true},
+		{"?bbox=77.5,12.9,77.7,13.1", true},
+		{"?bbox=77.5,12.9,77.7,13.1&zoom=23", true},
+		{"?bbox=77.7,12.9,77.5,13.1&zoom=12", true},
+	}
+	for _, tt := range tests {
+		newStubDB(t)
+		c, _ := newTestContext(http.MethodGet, "/complaints/clusters"+tt.query, "", nil)
+		if _, _, err := aggregationQuery(c); (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.query, err, tt.wantErr)
+		}
+	}
+}
+
+func TestComplaintClustersHandler(t *testing.T) {
+	stub := newStubDB(t)
+	stub.returns("jsonb_object_agg",
+		[]string{"count", "latitude", "longitude", "complaint_id", "by_status", "by_category"},
+		[]driver.Value{int64(3), 12.97, 77.59, nil, `{"pending": 2, "resolved": 1}`, `{"Roads": 3}`},
+		[]driver.Value{int64(1), 12.99, 77.61, int64(8), `{"pending": 1}`, `{"Water": 1}`})
+	c, w := newTestContext(http.MethodGet, "/complaints/clusters?bbox=77.5,12.9,77.7,13.1&zoom=12", "", nil)
+
+	complaintClustersHandler(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("code = %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Total    int                `json:"total"`
+		Clusters []ComplaintCluster `json:"clusters"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Total != 4 || len(body.Clusters) != 2 {
+		t.Fatalf("total %d over %d clusters", body.Total, len(body.Clusters))
+	}
+	if first := body.Clusters[0]; first.ComplaintID != nil || first.ByStatus["pending"] != 2 || first.ByCategory["Roads"] != 3 {
+		t.Errorf("first cluster = %+v", first)
+	}
+	if single := body.Clusters[1]; single.ComplaintID == nil || *single.ComplaintID != 8 {
+		t.Errorf("single-complaint cell = %+v", single)
+	}
+}
+
+func TestComplaintHeatmapHandler(t *testing.T) {
+	stub := newStubDB(t)
+	stub.returns("SUM(weight)", []string{"latitude", "longitude", "weight"},
+		[]driver.Value{12.97, 77.59, 7.0},
+		[]driver.Value{12.99, 77.61, 1.0})
+	c, w := newTestContext(http.MethodGet, "/complaints/heatmap?bbox=77.5,12.9,77.7,13.1&zoom=12", "", nil)
+
+	complaintHeatmapHandler(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("code = %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		MaxWeight float64        `json:"max_weight"`
+		Points    []HeatmapPoint `json:"points"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.MaxWeight != 7 || len(body.Points) != 2 {
+		t.Errorf("max_weight %v over %d points", body.MaxWeight, len(body.Points))
+	}
+	// Downvoted complaints still count once
+	if !stub.ran("GREATEST(upvotes - downvotes * 2, 0) + 1") {
+		t.Error("weights are not floored at one")
+	}
+}
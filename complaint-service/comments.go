@@ -0,0 +1,338 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ── Comments ────────────────────────────────────────────────────────────────
+//
+// Comments form threads: a reply names its parent_id and inherits the
+// thread's root_id, so a page of top-level comments loads with all of its
+// replies in one query. Citizens and staff both comment; a staff comment is
+// an official response tied to the admin who wrote it and emits a
+// complaint.official_response event.
+//
+// Authors may edit within COMMENT_EDIT_WINDOW (15m) — each edit keeps the
+// previous text in complaint_comment_edits — and delete within
+// COMMENT_DELETE_WINDOW (24h). Deleted and moderated (see moderation.go)
+// comments stay in the thread as blank placeholders so replies keep their
+// context.
+
+var (
+	errCommentNotFound = errors.New("comment not found")
+	errCommentDeleted  = errors.New("comment was deleted")
+	errNotCommentOwner = errors.New("only the author can change a comment")
+	errWindowClosed    = errors.New("the window for this change has closed")
+)
+
+// ComplaintCommentEdit — the text a comment had before an edit
+type ComplaintCommentEdit struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CommentID uint      `gorm:"index;not null" json:"comment_id"`
+	Content   string    `gorm:"type:text;not null" json:"content"`
+	EditedAt  time.Time `gorm:"not null" json:"edited_at"`
+}
+
+type commentBody struct {
+	Content  string `json:"content" binding:"required"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+func commentErrorCode(err error) int {
+	switch {
+	case errors.Is(err, errCommentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errCommentDeleted):
+		return http.StatusGone
+	case errors.Is(err, errNotCommentOwner), errors.Is(err, errWindowClosed):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// redact hides the text of deleted and moderated comments from readers.
+func (cc *ComplaintComment) redact() {
+	if cc.DeletedAt != nil || cc.ModerationStatus != ModerationVisible {
+		cc.Content = ""
+	}
+}
+
+func isCommentAuthor(c *gin.Context, comment *ComplaintComment) bool {
+	if adminID := getAdminID(c); adminID != 0 {
+		return comment.AdminID != nil && *comment.AdminID == adminID
+	}
+	return comment.AdminID == nil && comment.UserID == getUserID(c)
+}
+
+// loadOwnComment fetches a live comment on the complaint in :id that the
+// caller wrote and may still change within window.
+func loadOwnComment(c *gin.Context, window time.Duration) (*ComplaintComment, error) {
+	var comment ComplaintComment
+	db.Where("id = ? AND complaint_id = ?", c.Param("comment_id"), c.Param("id")).Limit(1).Find(&comment)
+	switch {
+	case comment.ID == 0:
+		return nil, errCommentNotFound
+	case comment.DeletedAt != nil:
+		return nil, errCommentDeleted
+	case !isCommentAuthor(c, &comment):
+		return nil, errNotCommentOwner
+	case time.Since(comment.CreatedAt) > window:
+		return nil, fmt.Errorf("%w (%s after posting)", errWindowClosed, window)
+	}
+	return &comment, nil
+}
+
+// GET /complaints/:id/comments?limit=&offset=&official=true
+//
+// Top-level comments newest first, each with its replies oldest first.
+// official=true lists only staff responses, without threading.
+func getCommentsHandler(c *gin.Context) {
+	complaintID := c.Param("id")
+	limit := pageLimit(c)
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := db.Model(&ComplaintComment{}).Where("complaint_id = ?", complaintID)
+	if c.Query("official") == "true" {
+		query = query.Where("official AND moderation_status = ?", ModerationVisible)
+	} else {
+		query = query.Where("parent_id IS NULL")
+	}
+	var total int64
+	query.Count(&total)
+	comments := []*ComplaintComment{}
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("official") != "true" && len(comments) > 0 {
+		byID := make(map[uint]*ComplaintComment, len(comments))
+		rootIDs := make([]uint, len(comments))
+		for i, cc := range comments {
+			byID[cc.ID] = cc
+			rootIDs[i] = cc.ID
+		}
+		var replies []*ComplaintComment
+		db.Where("root_id IN ?", rootIDs).Order("created_at, id").Find(&replies)
+		for _, r := range replies {
+			byID[r.ID] = r
+		}
+		// Oldest first, so a parent is always placed before its replies
+		for _, r := range replies {
+			if parent, ok := byID[*r.ParentID]; ok {
+				parent.Replies = append(parent.Replies, r)
+			}
+			r.redact()
+		}
+	}
+	for _, cc := range comments {
+		cc.redact()
+	}
+	c.JSON(http.StatusOK, gin.H{"comments": comments, "total": total, "limit": limit, "offset": offset})
+}
+
+// POST /complaints/:id/comments
+func addCommentHandler(c *gin.Context) {
+	complaintID, _ := strconv.Atoi(c.Param("id"))
+	var body commentBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	postComment(c, uint(complaintID), body)
+}
+
+// POST /complaints/comments — deprecated, complaint_id in the body
+func legacyAddCommentHandler(c *gin.Context) {
+	var body struct {
+		commentBody
+		ComplaintID uint `json:"complaint_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Deprecation", "true")
+	c.Header("Link", fmt.Sprintf(`</complaints/%d/comments>; rel="successor-version"`, body.ComplaintID))
+	postComment(c, body.ComplaintID, body.commentBody)
+}
+
+func postComment(c *gin.Context, complaintID uint, body commentBody) {
+	content := strings.TrimSpace(body.Content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "content is required"})
+		return
+	}
+	var complaint Complaint
+	if err := db.First(&complaint, complaintID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "complaint not found"})
+		return
+	}
+	if rejectBanned(c, complaint.GovernmentID) {
+		return
+	}
+	if complaint.MergedIntoID != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":          fmt.Sprintf("complaint was merged into #%d; comment there", *complaint.MergedIntoID),
+			"merged_into_id": *complaint.MergedIntoID,
+		})
+		return
+	}
+
+	comment := ComplaintComment{ComplaintID: complaint.ID, Content: content, ParentID: body.ParentID, ModerationStatus: ModerationVisible}
+	if adminID := getAdminID(c); adminID != 0 {
+		// Staff speak for the department, so only where they have jurisdiction
+		if !canManageComplaint(c, &complaint) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "complaint is outside your jurisdiction"})
+			return
+		}
+		comment.AdminID, comment.Official = &adminID, true
+	} else {
+		comment.UserID = getUserID(c)
+	}
+	if body.ParentID != nil {
+		var parent ComplaintComment
+		db.Where("id = ? AND complaint_id = ?", *body.ParentID, complaint.ID).Limit(1).Find(&parent)
+		if parent.ID == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "parent comment not found"})
+			return
+		}
+		comment.RootID = &parent.ID
+		if parent.RootID != nil {
+			comment.RootID = parent.RootID
+		}
+	}
+
+	held := screenContent(content)
+	if len(held) > 0 {
+		comment.ModerationStatus = ModerationHeld
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if len(held) > 0 {
+			if err := recordFilterHold(tx, "comment", comment.ID, &complaint, held); err != nil {
+				return err
+			}
+		}
+		if !comment.Official {
+			return nil
+		}
+		return enqueueComplaintEvent(tx, EventComplaintOfficialResponse, &complaint, map[string]interface{}{
+			"comment_id": comment.ID,
+			"admin_id":   *comment.AdminID,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, comment)
+}
+
+// PUT /complaints/:id/comments/:comment_id
+func editCommentHandler(c *gin.Context) {
+	var body struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	content := strings.TrimSpace(body.Content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "content is required"})
+		return
+	}
+	comment, err := loadOwnComment(c, envDuration("COMMENT_EDIT_WINDOW", 15*time.Minute))
+	if err != nil {
+		c.JSON(commentErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	if content == comment.Content {
+		c.JSON(http.StatusOK, comment)
+		return
+	}
+
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		edit := ComplaintCommentEdit{CommentID: comment.ID, Content: comment.Content, EditedAt: now}
+		if err := tx.Create(&edit).Error; err != nil {
+			return err
+		}
+		comment.Content, comment.EditedAt = content, &now
+		changes := map[string]interface{}{"content": content, "edited_at": now}
+		if held := screenContent(content); len(held) > 0 {
+			var complaint Complaint
+			if err := tx.First(&complaint, comment.ComplaintID).Error; err != nil {
+				return err
+			}
+			if err := recordFilterHold(tx, "comment", comment.ID, &complaint, held); err != nil {
+				return err
+			}
+			comment.ModerationStatus, changes["moderation_status"] = ModerationHeld, ModerationHeld
+		}
+		return tx.Model(comment).Updates(changes).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, comment)
+}
+
+// DELETE /complaints/:id/comments/:comment_id
+func deleteCommentHandler(c *gin.Context) {
+	comment, err := loadOwnComment(c, envDuration("COMMENT_DELETE_WINDOW", 24*time.Hour))
+	if err != nil {
+		c.JSON(commentErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err := db.Model(comment).Update("deleted_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// GET /complaints/:id/comments/:comment_id/history — earlier versions, oldest first
+//
+// Public, but held and hidden comments are only shown to their author and to
+// staff who manage the complaint.
+func commentHistoryHandler(c *gin.Context) {
+	var comment ComplaintComment
+	db.Where("id = ? AND complaint_id = ?", c.Param("comment_id"), c.Param("id")).Limit(1).Find(&comment)
+	if comment.ID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": errCommentNotFound.Error()})
+		return
+	}
+	if comment.DeletedAt != nil {
+		c.JSON(http.StatusGone, gin.H{"error": errCommentDeleted.Error()})
+		return
+	}
+	// Moderated text stays between its author and the staff handling it
+	if comment.ModerationStatus != ModerationVisible && !isCommentAuthor(c, &comment) {
+		var complaint Complaint
+		db.Select("id, government_id, department_id").Limit(1).Find(&complaint, comment.ComplaintID)
+		if complaint.ID == 0 || !canManageComplaint(c, &complaint) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errCommentNotFound.Error()})
+			return
+		}
+	}
+	edits := []ComplaintCommentEdit{}
+	db.Where("comment_id = ?", comment.ID).Order("edited_at, id").Find(&edits)
+	c.JSON(http.StatusOK, gin.H{"comment": comment, "edits": edits})
+}
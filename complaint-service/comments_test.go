@@ -0,0 +1,156 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var citizenClaims = map[string]interface{}{"user_id": float64(5)}
+
+// storedComment makes comment #3 on complaint #1, written by citizen 5
+// (or by admin 7 when staff) age ago, loadable.
+func storedComment(stub *stubDriver, age time.Duration, staff, deleted bool) {
+	var adminID, deletedAt driver.Value
+	if staff {
+		adminID = int64(7)
+	}
+	if deleted {
+		deletedAt = time.Now().Add(-time.Minute)
+	}
+	stub.returns(`FROM "complaint_comments"`,
+		[]string{"id", "complaint_id", "user_id", "admin_id", "content", "moderation_status", "created_at", "deleted_at"},
+		[]driver.Value{int64(3), int64(1), int64(5), adminID, "Still broken", ModerationVisible, time.Now().Add(-age), deletedAt})
+}
+
+func TestEditCommentHandler(t *testing.T) {
+	staff := map[string]interface{}{"admin_id": float64(7), "admin_role": "dept_manager", "government_id": float64(2)}
+	tests := []struct {
+		name       string
+		claims     map[string]interface{}
+		stored     bool
+		age        time.Duration
+		staff      bool
+		deleted    bool
+		body       string
+		window     string
+		wantCode   int
+		wantEdited bool
+	}{
+		{name: "author within window", claims: citizenClaims, stored: true, age: 5 * time.Minute, body: `{"content":"Still broken today"}`, wantCode: http.StatusOK, wantEdited: true},
+		{name: "window closed", claims: citizenClaims, stored: true, age: 16 * time.Minute, body: `{"content":"Still broken today"}`, wantCode: http.StatusForbidden},
+		{name: "configured window", claims: citizenClaims, stored: true, age: 16 * time.Minute, window: "1h", body: `{"content":"Still broken today"}`, wantCode: http.StatusOK, wantEdited: true},
+		{name: "unchanged text", claims: citizenClaims, stored: true, age: time.Minute, body: `{"content":" Still broken "}`, wantCode: http.StatusOK},
+		{name: "blank text", claims: citizenClaims, stored: true, age: time.Minute, body: `{"content":"   "}`, wantCode: http.StatusBadRequest},
+		{name: "another citizen", claims: map[string]interface{}{"user_id": float64(6)}, stored: true, age: time.Minute, body: `{"content":"x"}`, wantCode: http.StatusForbidden},
+		{name: "staff on a citizen comment", claims: staff, stored: true, age: time.Minute, body: `{"content":"x"}`, wantCode: http.StatusForbidden},
+		{name: "staff on own response", claims: staff, stored: true, staff: true, age: time.Minute, body: `{"content":"Crew sent"}`, wantCode: http.StatusOK, wantEdited: true},
+		{name: "citizen 5 on a staff response", claims: citizenClaims, stored: true, staff: true, age: time.Minute, body: `{"content":"x"}`, wantCode: http.StatusForbidden},
+		{name: "deleted", claims: citizenClaims, stored: true, deleted: true, age: time.Minute, body: `{"content":"x"}`, wantCode: http.StatusGone},
+		{name: "missing", claims: citizenClaims, body: `{"content":"x"}`, wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.window != "" {
+				t.Setenv("COMMENT_EDIT_WINDOW", tt.window)
+			}
+			stub := newStubDB(t)
+			if tt.stored {
+				storedComment(stub, tt.age, tt.staff, tt.deleted)
+			}
+			stub.returns(`INSERT INTO "complaint_comment_edits"`, []string{"id"}, []driver.Value{int64(1)})
+			c, w := newTestContext(http.MethodPut, "/complaints/1/comments/3", tt.body, tt.claims)
+			c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "comment_id", Value: "3"}}
+
+			editCommentHandler(c)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if kept := stub.ran(`INSERT INTO "complaint_comment_edits"`); kept != tt.wantEdited {
+				t.Errorf("previous text kept = %v, want %v", kept, tt.wantEdited)
+			}
+			if edited := stub.ran(`UPDATE "complaint_comments"`); edited != tt.wantEdited {
+				t.Errorf("comment updated = %v, want %v", edited, tt.wantEdited)
+			}
+		})
+	}
+}
+
+func TestDeleteCommentHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		age      time.Duration
+		window   string
+		wantCode int
+	}{
+		{name: "within a day", age: 23 * time.Hour, wantCode: http.StatusOK},
+		{name: "after a day", age: 25 * time.Hour, wantCode: http.StatusForbidden},
+		{name: "configured window", age: 2 * time.Hour, window: "1h", wantCode: http.StatusForbidden},
+		{name: "invalid window falls back", age: 2 * time.Hour, window: "soon", wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.window != "" {
+				t.Setenv("COMMENT_DELETE_WINDOW", tt.window)
+			}
+			stub := newStubDB(t)
+			storedComment(stub, tt.age, false, false)
+			c, w := newTestContext(http.MethodDelete, "/complaints/1/comments/3", "", citizenClaims)
+			c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "comment_id", Value: "3"}}
+
+			deleteCommentHandler(c)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			// Deleting keeps the row as a placeholder for its replies
+			if softDeleted := stub.ran(`SET "deleted_at"`); softDeleted != (tt.wantCode == http.StatusOK) {
+				t.Errorf("soft-deleted = %v", softDeleted)
+			}
+			if stub.ran(`DELETE FROM`) {
+				t.Error("comment row removed")
+			}
+		})
+	}
+}
+
+func TestCommentRedact(t *testing.T) {
+	deleted := time.Now()
+	tests := []struct {
+		name    string
+		comment ComplaintComment
+		want    string
+	}{
+		{name: "visible", comment: ComplaintComment{Content: "text", ModerationStatus: ModerationVisible}, want: "text"},
+		{name: "deleted", comment: ComplaintComment{Content: "text", ModerationStatus: ModerationVisible, DeletedAt: &deleted}},
+		{name: "held", comment: ComplaintComment{Content: "text", ModerationStatus: ModerationHeld}},
+	}
+	for _, tt := range tests {
+		tt.comment.redact()
+		if tt.comment.Content != tt.want {
+			t.Errorf("%s: content = %q, want %q", tt.name, tt.comment.Content, tt.want)
+		}
+	}
+}
+
+func TestCommentErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errCommentNotFound, 404},
+		{errCommentDeleted, 410},
+		{errNotCommentOwner, 403},
+		{fmt.Errorf("%w (15m0s after posting)", errWindowClosed), 403},
+		{errors.New("db down"), 500},
+	}
+	for _, tt := range tests {
+		if got := commentErrorCode(tt.err); got != tt.want {
+			t.Errorf("commentErrorCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ── Citizen Confirmation ────────────────────────────────────────────────────
+//
+// A resolved complaint is not closed by the department. Resolution opens a
+// CONFIRMATION_WINDOW (168h) for the citizen who filed it to either
+//   POST /complaints/:id/confirm {rating 1–5, feedback}   resolved → closed
+//   POST /complaints/:id/reopen  {reason, evidence_urls}  resolved → reopened
+// Unanswered complaints are closed by the confirmation sweeper once the
+// window passes. Every outcome is a ComplaintConfirmation row; together with
+// the dashboard's reopened counters they make up the department metrics at
+// GET /complaints/metrics/departments.
+
+const (
+	ConfirmationAccepted   = "accepted"
+	ConfirmationReopened   = "reopened"
+	ConfirmationAutoClosed = "auto_closed"
+)
+
+var (
+	errComplaintNotFound   = errors.New("complaint not found")
+	errNotComplaintOwner   = errors.New("only the citizen who filed the complaint can respond")
+	errNotAwaitingResponse = errors.New("complaint is not awaiting confirmation")
+	errConfirmationExpired = errors.New("the confirmation window has closed")
+	errCitizenConfirms     = errors.New("a resolved complaint is closed by the citizen's confirmation or the auto-close, not by staff")
+)
+
+// ComplaintConfirmation — the outcome of one resolution
+type ComplaintConfirmation struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ComplaintID  uint      `gorm:"index;not null" json:"complaint_id"`
+	GovernmentID uint      `gorm:"index;not null" json:"government_id"`
+	DepartmentID *uint     `gorm:"index" json:"department_id,omitempty"`
+	UserID       uint      `json:"user_id,omitempty"` // 0 when auto-closed
+	Outcome      string    `gorm:"not null" json:"outcome"`
+	Rating       *int      `json:"rating,omitempty"`
+	Feedback     string    `gorm:"type:text" json:"feedback,omitempty"`
+	EvidenceURLs string    `gorm:"type:text" json:"evidence_urls,omitempty"` // JSON array
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func confirmationWindow() time.Duration {
+	return envDuration("CONFIRMATION_WINDOW", 7*24*time.Hour)
+}
+
+func confirmationErrorCode(err error) int {
+	switch {
+	case errors.Is(err, errComplaintNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errNotComplaintOwner):
+		return http.StatusForbidden
+	case errors.Is(err, errForeignMedia):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, errNotAwaitingResponse), errors.Is(err, errConfirmationExpired):
+		return http.StatusConflict
+	}
+	return statusErrorCode(err)
+}
+
+// loadAwaitingConfirmation fetches the caller's resolved complaint in :id
+// while its confirmation window is open.
+func loadAwaitingConfirmation(c *gin.Context) (*Complaint, error) {
+	var complaint Complaint
+	if err := db.First(&complaint, c.Param("id")).Error; err != nil {
+		return nil, errComplaintNotFound
+	}
+	switch {
+	case complaint.UserID != getUserID(c):
+		return nil, errNotComplaintOwner
+	case complaint.Status != StatusResolved:
+		return nil, errNotAwaitingResponse
+	case complaint.ConfirmationDueAt != nil && time.Now().After(*complaint.ConfirmationDueAt):
+		return nil, errConfirmationExpired
+	}
+	return &complaint, nil
+}
+
+func newConfirmation(complaint *Complaint, outcome string, userID uint) ComplaintConfirmation {
+	return ComplaintConfirmation{
+		ComplaintID:  complaint.ID,
+		GovernmentID: complaint.GovernmentID,
+		DepartmentID: complaint.DepartmentID,
+		UserID:       userID,
+		Outcome:      outcome,
+	}
+}
+
+// POST /complaints/:id/confirm
+func confirmResolutionHandler(c *gin.Context) {
+	var body struct {
+		Rating   int    `json:"rating" binding:"required,min=1,max=5"`
+		Feedback string `json:"feedback"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	complaint, err := loadAwaitingConfirmation(c)
+	if err != nil {
+		c.JSON(confirmationErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	confirmation := newConfirmation(complaint, ConfirmationAccepted, getUserID(c))
+	confirmation.Rating, confirmation.Feedback = &body.Rating, body.Feedback
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := transitionStatus(tx, complaint, StatusClosed, actorFromContext(c), "confirmed by citizen"); err != nil {
+			return err
+		}
+		if err := tx.Create(&confirmation).Error; err != nil {
+			return err
+		}
+		complaint.SatisfactionRating = &body.Rating
+		return tx.Model(complaint).UpdateColumn("satisfaction_rating", body.Rating).Error
+	})
+	if err != nil {
+		c.JSON(confirmationErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"complaint": complaint, "confirmation": confirmation})
+}
+
+// checkEvidenceURLs accepts only photos the caller uploaded through us, the
+// same rule as complaint photos (see imagehash.go).
+func checkEvidenceURLs(c *gin.Context, urls []string) error {
+	prefixes := make([]string, 0, len(urls))
+	for _, u := range urls {
+		prefix, ok := mediaPrefixFromURL(u)
+		if !ok {
+			return fmt.Errorf("%w: %q is not an uploaded photo", errForeignMedia, u)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	if len(prefixes) == 0 {
+		return nil
+	}
+	var media []MediaObject
+	if err := db.Where("prefix IN ?", prefixes).Find(&media).Error; err != nil {
+		return err
+	}
+	found := map[string]bool{}
+	for _, m := range media {
+		found[m.Prefix] = true
+	}
+	for _, prefix := range prefixes {
+		if !found[prefix] {
+			return fmt.Errorf("%w: %s", errForeignMedia, mediaURL(prefix))
+		}
+	}
+	return checkMediaOwnership(c, media, 0)
+}
+
+// POST /complaints/:id/reopen
+func reopenComplaintHandler(c *gin.Context) {
+	var body struct {
+		Reason       string   `json:"reason" binding:"required"`
+		EvidenceURLs []string `json:"evidence_urls"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	complaint, err := loadAwaitingConfirmation(c)
+	if err != nil {
+		c.JSON(confirmationErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := checkEvidenceURLs(c, body.EvidenceURLs); err != nil {
+		c.JSON(confirmationErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	confirmation := newConfirmation(complaint, ConfirmationReopened, getUserID(c))
+	confirmation.Feedback = body.Reason
+	if len(body.EvidenceURLs) > 0 {
+		evidence, _ := json.Marshal(body.EvidenceURLs)
+		confirmation.EvidenceURLs = string(evidence)
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := transitionStatus(tx, complaint, StatusReopened, actorFromContext(c), body.Reason); err != nil {
+			return err
+		}
+		return tx.Create(&confirmation).Error
+	})
+	if err != nil {
+		c.JSON(confirmationErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"complaint": complaint, "confirmation": confirmation})
+}
+
+// ── Confirmation Sweeper ──
+
+// autoCloseUnconfirmed closes resolved complaints whose window has passed,
+// one transaction each so a concurrent citizen response simply wins.
+func autoCloseUnconfirmed() {
+	// Complaints resolved before confirmations existed get a fresh window
+	db.Model(&Complaint{}).Where("status = ? AND confirmation_due_at IS NULL", StatusResolved).
+		Update("confirmation_due_at", time.Now().Add(confirmationWindow()))
+
+	var due []Complaint
+	db.Where("status = ? AND confirmation_due_at < ?", StatusResolved, time.Now()).
+		Order("confirmation_due_at").Limit(500).Find(&due)
+	closed := 0
+	for i := range due {
+		complaint := &due[i]
+		err := db.Transaction(func(tx *gorm.DB) error {
+			reason := fmt.Sprintf("no citizen response within %s", confirmationWindow())
+			if err := transitionStatus(tx, complaint, StatusClosed, systemActor, reason); err != nil {
+				return err
+			}
+			confirmation := newConfirmation(complaint, ConfirmationAutoClosed, 0)
+			return tx.Create(&confirmation).Error
+		})
+		if err != nil {
+			if !errors.Is(err, errConcurrentChange) {
+				log.Printf("[complaint-service] Auto-close of complaint %d failed: %v", complaint.ID, err)
+			}
+			continue
+		}
+		closed++
+	}
+	if closed > 0 {
+		log.Printf("[complaint-service] Confirmation sweep: %d complaints auto-closed", closed)
+	}
+}
+
+func runConfirmationSweeper() {
+	ticker := time.NewTicker(envDuration("CONFIRMATION_SWEEP_INTERVAL", 10*time.Minute))
+	defer ticker.Stop()
+	for range ticker.C {
+		autoCloseUnconfirmed()
+	}
+}
+
+// ── Department Metrics ──
+
+// GET /complaints/metrics/departments?government_id=&from=&to=
+//
+// Confirmation outcomes per department, counted by when they happened.
+// Dept managers only see their own department.
+func departmentMetricsHandler(c *gin.Context) {
+	query := db.Model(&ComplaintConfirmation{})
+	switch getAdminRole(c) {
+	case "super_admin":
+		if govID := c.Query("government_id"); govID != "" {
+			query = query.Where("government_id = ?", govID)
+		}
+	case "manager":
+		query = query.Where("government_id = ?", getGovID(c))
+	default:
+		deptID := getDeptID(c)
+		if deptID == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "no department on token"})
+			return
+		}
+		query = query.Where("government_id = ? AND department_id = ?", getGovID(c), *deptID)
+	}
+	if from := c.Query("from"); from != "" {
+		t, err := parseFilterTime(from, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("created_at >= ?", t)
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := parseFilterTime(to, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("created_at <= ?", t)
+	}
+
+	type departmentMetrics struct {
+		GovernmentID  uint     `json:"government_id"`
+		DepartmentID  *uint    `json:"department_id"`
+		Resolutions   int64    `json:"resolutions"`
+		Accepted      int64    `json:"accepted"`
+		Reopened      int64    `json:"reopened"`
+		AutoClosed    int64    `json:"auto_closed"`
+		ReopenRate    float64  `json:"reopen_rate"`
+		AverageRating *float64 `json:"average_rating"`
+	}
+	metrics := []departmentMetrics{}
+	err := query.Select(`government_id, department_id, COUNT(*) AS resolutions,
+			COUNT(*) FILTER (WHERE outcome = ?) AS accepted,
+			COUNT(*) FILTER (WHERE outcome = ?) AS reopened,
+			COUNT(*) FILTER (WHERE outcome = ?) AS auto_closed,
+			ROUND(AVG(rating)::numeric, 2)::float8 AS average_rating`,
+		ConfirmationAccepted, ConfirmationReopened, ConfirmationAutoClosed).
+		Group("government_id, department_id").
+		Order("government_id, department_id NULLS FIRST").
+		Scan(&metrics).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range metrics {
+		if metrics[i].Resolutions > 0 {
+			rate := float64(metrics[i].Reopened) / float64(metrics[i].Resolutions)
+			metrics[i].ReopenRate = math.Round(rate*1000) / 1000
+		}
+	}
+	c.JSON(http.StatusOK, metrics)
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ── Dashboard Counters (Redis) ──────────────────────────────────────────────
+//
+// admin-service's dashboard reads:
+//   dashboard:{gov}:pending | :in_progress | :resolved | :reopened
+//   dashboard:{gov}:dept:{dept}:pending | :in_progress | :resolved | :reopened
+// reopened counts reopen transitions (not current status) and follows the
+// complaint when it is reassigned.
+// They are maintained from our own complaint_events (created, status_changed,
+// reassigned) so counters only move for committed changes. Each event is
+// applied at most once via a Lua script keyed on the outbox message id.
+// Drift can be repaired with:  ./complaint-service reconcile-dashboard
+
+const dashboardCountersQueue = "complaint_dashboard_counters"
+
+// statusBucket maps lifecycle statuses onto the three dashboard buckets.
+func statusBucket(status string) string {
+	switch status {
+	case StatusPending, StatusAcknowledged, StatusReopened:
+		return "pending"
+	case StatusInProgress, StatusPendingVerification:
+		return "in_progress"
+	case StatusResolved, StatusClosed:
+		return "resolved"
+	}
+	return "" // rejected, merged: not counted
+}
+
+func govCounterKey(govID uint, bucket string) string {
+	return fmt.Sprintf("dashboard:%d:%s", govID, bucket)
+}
+
+func deptCounterKey(govID, deptID uint, bucket string) string {
+	return fmt.Sprintf("dashboard:%d:dept:%d:%s", govID, deptID, bucket)
+}
+
+// applyCounterScript: KEYS[1] is the dedupe marker, KEYS[2..] the counters;
+// ARGV[1] is the marker TTL, ARGV[2..] the increments.
+var applyCounterScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], 1, 'NX', 'EX', ARGV[1]) then
+	for i = 2, #KEYS do
+		redis.call('INCRBY', KEYS[i], ARGV[i])
+	end
+	return 1
+end
+return 0
+`)
+
+type counterEvent struct {
+	Event            string `json:"event"`
+	GovernmentID     uint   `json:"government_id"`
+	DepartmentID     *uint  `json:"department_id"`
+	Status           string `json:"status"`
+	FromStatus       string `json:"from_status"`
+	ToStatus         string `json:"to_status"`
+	FromDepartmentID *uint  `json:"from_department_id"`
+	ReopenCount      int64  `json:"reopen_count"`
+}
+
+// counterDeltas computes key → increment for one complaint event.
+func counterDeltas(ev counterEvent) map[string]int64 {
+	deltas := map[string]int64{}
+	add := func(deptID *uint, status string, n int64, govLevel bool) {
+		bucket := statusBucket(status)
+		if bucket == "" {
+			return
+		}
+		if govLevel {
+			deltas[govCounterKey(ev.GovernmentID, bucket)] += n
+		}
+		if deptID != nil {
+			deltas[deptCounterKey(ev.GovernmentID, *deptID, bucket)] += n
+		}
+	}
+	switch ev.Event {
+	case EventComplaintCreated:
+		add(ev.DepartmentID, ev.Status, 1, true)
+	case EventComplaintStatusChanged:
+		add(ev.DepartmentID, ev.FromStatus, -1, true)
+		add(ev.DepartmentID, ev.ToStatus, 1, true)
+		if ev.ToStatus == StatusReopened {
+			deltas[govCounterKey(ev.GovernmentID, "reopened")]++
+			if ev.DepartmentID != nil {
+				deltas[deptCounterKey(ev.GovernmentID, *ev.DepartmentID, "reopened")]++
+			}
+		}
+	case EventComplaintReassigned:
+		add(ev.FromDepartmentID, ev.Status, -1, false)
+		add(ev.DepartmentID, ev.Status, 1, false)
+		if ev.ReopenCount > 0 {
+			if ev.FromDepartmentID != nil {
+				deltas[deptCounterKey(ev.GovernmentID, *ev.FromDepartmentID, "reopened")] -= ev.ReopenCount
+			}
+			if ev.DepartmentID != nil {
+				deltas[deptCounterKey(ev.GovernmentID, *ev.DepartmentID, "reopened")] += ev.ReopenCount
+			}
+		}
+	}
+	for k, v := range deltas {
+		if v == 0 {
+			delete(deltas, k)
+		}
+	}
+	return deltas
+}
+
+func applyCounterEvent(ctx context.Context, messageID string, body []byte) error {
+	var ev counterEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return err
+	}
+	deltas := counterDeltas(ev)
+	if len(deltas) == 0 {
+		return nil
+	}
+	keys := []string{"dashboard:applied:" + messageID}
+	args := []interface{}{int((7 * 24 * time.Hour).Seconds())}
+	for k, v := range deltas {
+		keys = append(keys, k)
+		args = append(args, v)
+	}
+	return applyCounterScript.Run(ctx, rdb, keys, args...).Err()
+}
+
+func startDashboardConsumer() {
+	ch, err := amqpConn.Channel()
+	if err != nil {
+		log.Printf("[complaint-service] Dashboard consumer channel failed: %v", err)
+		return
+	}
+	if err := ch.ExchangeDeclare(complaintEventsExchange, "topic", true, false, false, false, nil); err != nil {
+		log.Printf("[complaint-service] Dashboard exchange declare failed: %v", err)
+		return
+	}
+	if _, err := ch.QueueDeclare(dashboardCountersQueue, true, false, false, false, nil); err != nil {
+		log.Printf("[complaint-service] Dashboard queue declare failed: %v", err)
+		return
+	}
+	for _, key := range []string{EventComplaintCreated, EventComplaintStatusChanged, EventComplaintReassigned} {
+		if err := ch.QueueBind(dashboardCountersQueue, key, complaintEventsExchange, false, nil); err != nil {
+			log.Printf("[complaint-service] Dashboard queue bind failed: %v", err)
+			return
+		}
+	}
+	// One at a time keeps counter updates in publish order
+	ch.Qos(1, 0, false)
+	msgs, err := ch.Consume(dashboardCountersQueue, "complaint-service-dashboard", false, false, false, false, nil)
+	if err != nil {
+		log.Printf("[complaint-service] Dashboard consume failed: %v", err)
+		return
+	}
+	log.Printf("[complaint-service] 👂 Maintaining dashboard counters from [%s]", dashboardCountersQueue)
+
+	go func() {
+		for msg := range msgs {
+			if msg.MessageId == "" {
+				msg.Nack(false, false)
+				continue
+			}
+			if err := applyCounterEvent(context.Background(), msg.MessageId, msg.Body); err != nil {
+				log.Printf("[complaint-service] Dashboard counter update failed: %v", err)
+				msg.Nack(false, true)
+				time.Sleep(time.Second)
+				continue
+			}
+			msg.Ack(false)
+		}
+		log.Println("[complaint-service] Dashboard consumer stopped (channel closed)")
+	}()
+}
+
+// ── Reconciliation ──────────────────────────────────────────────────────────
+
+// reconcileDashboard rebuilds every counter from Postgres and replaces the
+// current values in a single MULTI/EXEC.
+func reconcileDashboard(ctx context.Context) error {
+	var rows []struct {
+		GovernmentID uint
+		DepartmentID *uint
+		Status       string
+		Count        int64
+		Reopened     int64
+	}
+	err := db.Model(&Complaint{}).
+		Select("government_id, department_id, status, COUNT(*) AS count, SUM(reopen_count) AS reopened").
+		Group("government_id, department_id, status").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	values := map[string]int64{}
+	for _, row := range rows {
+		if row.Reopened > 0 {
+			values[govCounterKey(row.GovernmentID, "reopened")] += row.Reopened
+			if row.DepartmentID != nil {
+				values[deptCounterKey(row.GovernmentID, *row.DepartmentID, "reopened")] += row.Reopened
+			}
+		}
+		bucket := statusBucket(row.Status)
+		if bucket == "" {
+			continue
+		}
+		values[govCounterKey(row.GovernmentID, bucket)] += row.Count
+		if row.DepartmentID != nil {
+			values[deptCounterKey(row.GovernmentID, *row.DepartmentID, bucket)] += row.Count
+		}
+	}
+
+	var stale []string
+	for _, pattern := range []string{"dashboard:*:pending", "dashboard:*:in_progress", "dashboard:*:resolved", "dashboard:*:reopened"} {
+		iter := rdb.Scan(ctx, 0, pattern, 500).Iterator()
+		for iter.Next(ctx) {
+			if _, ok := values[iter.Val()]; !ok {
+				stale = append(stale, iter.Val())
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+	}
+
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(stale) > 0 {
+			pipe.Del(ctx, stale...)
+		}
+		for key, v := range values {
+			pipe.Set(ctx, key, strconv.FormatInt(v, 10), 0)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("[complaint-service] Dashboard reconciled: %d counters set, %d stale removed", len(values), len(stale))
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatusBucket(t *testing.T) {
+	for status, want := range map[string]string{
+		StatusPending:             "pending",
+		StatusAcknowledged:        "pending",
+		StatusReopened:            "pending",
+		StatusInProgress:          "in_progress",
+		StatusPendingVerification: "in_progress",
+		StatusResolved:            "resolved",
+		StatusClosed:              "resolved",
+		StatusRejected:            "",
+		StatusMerged:              "",
+	} {
+		if got := statusBucket(status); got != want {
+			t.Errorf("statusBucket(%s) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestCounterDeltas(t *testing.T) {
+	dept := func(id uint) *uint { return &id }
+	tests := []struct {
+		name string
+		ev   counterEvent
+		want map[string]int64
+	}{
+		{
+			name: "created without department",
+			ev:   counterEvent{Event: EventComplaintCreated, GovernmentID: 1, Status: StatusPending},
+			want: map[string]int64{"dashboard:1:pending": 1},
+		},
+		{
+			name: "created with department",
+			ev:   counterEvent{Event: EventComplaintCreated, GovernmentID: 1, DepartmentID: dept(4), Status: StatusPending},
+			want: map[string]int64{"dashboard:1:pending": 1, "dashboard:1:dept:4:pending": 1},
+		},
+		{
+			name: "status moves between buckets",
+			ev: counterEvent{Event: EventComplaintStatusChanged, GovernmentID: 1, DepartmentID: dept(4),
+				FromStatus: StatusInProgress, ToStatus: StatusResolved},
+			want: map[string]int64{
+				"dashboard:1:in_progress": -1, "dashboard:1:dept:4:in_progress": -1,
+				"dashboard:1:resolved": 1, "dashboard:1:dept:4:resolved": 1,
+			},
+		},
+		{
+			name: "status within one bucket",
+			ev:   counterEvent{Event: EventComplaintStatusChanged, GovernmentID: 1, FromStatus: StatusPending, ToStatus: StatusAcknowledged},
+			want: map[string]int64{},
+		},
+		{
+			name: "rejected leaves the counters",
+			ev:   counterEvent{Event: EventComplaintStatusChanged, GovernmentID: 1, FromStatus: StatusPending, ToStatus: StatusRejected},
+			want: map[string]int64{"dashboard:1:pending": -1},
+		},
+		{
+			name: "reopen counts the transition",
+			ev: counterEvent{Event: EventComplaintStatusChanged, GovernmentID: 1, DepartmentID: dept(4),
+				FromStatus: StatusResolved, ToStatus: StatusReopened},
+			want: map[string]int64{
+				"dashboard:1:resolved": -1, "dashboard:1:dept:4:resolved": -1,
+				"dashboard:1:pending": 1, "dashboard:1:dept:4:pending": 1,
+				"dashboard:1:reopened": 1, "dashboard:1:dept:4:reopened": 1,
+			},
+		},
+		{
+			name: "reassigned moves department counters only",
+			ev: counterEvent{Event: EventComplaintReassigned, GovernmentID: 1, FromDepartmentID: dept(4), DepartmentID: dept(5),
+				Status: StatusInProgress, ReopenCount: 2},
+			want: map[string]int64{
+				"dashboard:1:dept:4:in_progress": -1, "dashboard:1:dept:5:in_progress": 1,
+				"dashboard:1:dept:4:reopened": -2, "dashboard:1:dept:5:reopened": 2,
+			},
+		},
+		{
+			name: "first assignment",
+			ev:   counterEvent{Event: EventComplaintReassigned, GovernmentID: 1, DepartmentID: dept(5), Status: StatusPending},
+			want: map[string]int64{"dashboard:1:dept:5:pending": 1},
+		},
+		{
+			name: "other events",
+			ev:   counterEvent{Event: EventComplaintActionAdded, GovernmentID: 1, Status: StatusInProgress},
+			want: map[string]int64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := counterDeltas(tt.ev); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("counterDeltas() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ── Duplicate Detection (PostGIS + pg_trgm) ─────────────────────────────────
+
+// withinRadiusSQL is the PostGIS predicate shared by nearby search and
+// duplicate detection. Args: lng, lat, radius (meters).
+const withinRadiusSQL = `ST_DWithin(
+	ST_MakePoint(longitude, latitude)::geography,
+	ST_MakePoint(?, ?)::geography,
+	?
+)`
+
+// DuplicateCandidate — an open complaint that looks like the one being filed
+type DuplicateCandidate struct {
+	Complaint
+	DistanceMeters float64 `json:"distance_m"`
+	Similarity     float64 `json:"similarity"`
+}
+
+type duplicateQuery struct {
+	Category    string  `json:"category" binding:"required"`
+	Description string  `json:"description" binding:"required"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+}
+
+func duplicateRadiusMeters() float64 {
+	radius, err := strconv.ParseFloat(env("DUPLICATE_RADIUS_METERS", "100"), 64)
+	if err != nil || radius <= 0 {
+		radius = 100
+	}
+	return radius
+}
+
+func duplicateSimilarityThreshold() float64 {
+	threshold, err := strconv.ParseFloat(env("DUPLICATE_SIMILARITY_THRESHOLD", "0.3"), 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		threshold = 0.3
+	}
+	return threshold
+}
+
+// findDuplicateCandidates returns open complaints in the same category within
+// the configured radius whose descriptions are trigram-similar.
+func findDuplicateCandidates(q duplicateQuery) ([]DuplicateCandidate, error) {
+	candidates := []DuplicateCandidate{}
+	if q.Latitude == 0 && q.Longitude == 0 {
+		return candidates, nil
+	}
+	query := `
+		SELECT *,
+			ST_Distance(ST_MakePoint(longitude, latitude)::geography, ST_MakePoint(?, ?)::geography) AS distance_meters,
+			similarity(description, ?) AS similarity
+		FROM complaints
+		WHERE ` + withinRadiusSQL + `
+			AND LOWER(category) = LOWER(?)
+			AND status IN ?
+			AND moderation_status = 'visible'
+			AND similarity(description, ?) >= ?
+		ORDER BY similarity DESC, distance_meters ASC
+		LIMIT 5
+	`
+	err := db.Raw(query,
+		q.Longitude, q.Latitude, q.Description,
+		q.Longitude, q.Latitude, duplicateRadiusMeters(),
+		q.Category, openStatuses,
+		q.Description, duplicateSimilarityThreshold(),
+	).Scan(&candidates).Error
+	return candidates, err
+}
+
+// Dry run: what would createComplaintHandler flag as duplicates?
+func checkDuplicatesHandler(c *gin.Context) {
+	var q duplicateQuery
+	if err := c.ShouldBindJSON(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	candidates, err := findDuplicateCandidates(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"duplicates": candidates})
+}
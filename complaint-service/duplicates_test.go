@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateSettings(t *testing.T) {
+	tests := []struct {
+		radius, threshold         string
+		wantRadius, wantThreshold float64
+	}{
+		{"", "", 100, 0.3},
+		{"250", "0.5", 250, 0.5},
+		{"-5", "0", 100, 0.3},
+		{"far", "1.5", 100, 0.3},
+		{"50.5", "1", 50.5, 1},
+	}
+	for _, tt := range tests {
+		t.Setenv("DUPLICATE_RADIUS_METERS", tt.radius)
+		t.Setenv("DUPLICATE_SIMILARITY_THRESHOLD", tt.threshold)
+		if got := duplicateRadiusMeters(); got != tt.wantRadius {
+			t.Errorf("radius %q = %v, want %v", tt.radius, got, tt.wantRadius)
+		}
+		if got := duplicateSimilarityThreshold(); got != tt.wantThreshold {
+			t.Errorf("threshold %q = %v, want %v", tt.threshold, got, tt.wantThreshold)
+		}
+	}
+}
+
+func TestFindDuplicateCandidates(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     duplicateQuery
+		wantCount int
+		wantQuery bool
+	}{
+		{name: "no location", query: duplicateQuery{Category: "Roads", Description: "pothole"}},
+		{name: "nearby and similar", query: duplicateQuery{Category: "Roads", Description: "pothole", Latitude: 12.97, Longitude: 77.59}, wantCount: 2, wantQuery: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.returns("FROM complaints",
+				[]string{"id", "category", "status", "distance_meters", "similarity"},
+				[]driver.Value{int64(4), "Roads", StatusPending, 12.5, 0.8},
+				[]driver.Value{int64(6), "Roads", StatusInProgress, 60.0, 0.4})
+
+			candidates, err := findDuplicateCandidates(tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if candidates == nil || len(candidates) != tt.wantCount {
+				t.Fatalf("candidates = %v, want %d", candidates, tt.wantCount)
+			}
+			if tt.wantCount > 0 && (candidates[0].ID != 4 || candidates[0].DistanceMeters != 12.5 || candidates[0].Similarity != 0.8) {
+				t.Errorf("first candidate = #%d %vm %v", candidates[0].ID, candidates[0].DistanceMeters, candidates[0].Similarity)
+			}
+			if ran := stub.ran("similarity(description"); ran != tt.wantQuery {
+				t.Errorf("queried = %v, want %v", ran, tt.wantQuery)
+			}
+			// Closed, rejected and merged complaints are not worth upvoting
+			if tt.wantQuery && !stub.ran("status IN (") {
+				t.Error("candidates are not limited to open complaints")
+			}
+		})
+	}
+}
+
+func TestCheckDuplicatesHandler(t *testing.T) {
+	tests := []struct {
+		body     string
+		wantCode int
+	}{
+		{`{"category":"Roads","description":"pothole","latitude":12.97,"longitude":77.59}`, http.StatusOK},
+		{`{"category":"Roads"}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		newStubDB(t)
+		c, w := newTestContext(http.MethodPost, "/complaints/check-duplicates", tt.body, nil)
+
+		checkDuplicatesHandler(c)
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: code = %d, want %d", tt.body, w.Code, tt.wantCode)
+		}
+		if tt.wantCode == http.StatusOK && !strings.Contains(w.Body.String(), `"duplicates":[]`) {
+			t.Errorf("body = %s, want an empty list", w.Body)
+		}
+	}
+}
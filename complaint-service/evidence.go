@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"path"
+	"strings"
+	"time"
+	_ "time/tzdata" // PHOTO_TIMEZONE must resolve in slim images
+
+	"github.com/rwcarlsen/goexif/exif"
+	"gorm.io/gorm"
+)
+
+// ── Photo Evidence (EXIF GPS + timestamp) ───────────────────────────────────
+//
+// Uploads are re-encoded without metadata (media.go), so the EXIF GPS fix and
+// capture time are read first and kept on the MediaObject row, never in the
+// stored file. When a complaint is filed its photos are compared with its
+// location and filing time:
+//   verified           a photo was taken within PHOTO_MAX_DISTANCE_M (200)
+//                      of the complaint and within PHOTO_MAX_AGE (72h) of it
+//   location_mismatch  the nearest geotagged photo is farther than that
+//   time_mismatch      close enough, but taken outside the time window
+//   location_only      close enough, but the photo carries no capture time
+//   unverified         no photo carries a GPS fix
+// A complaint filed without coordinates takes the nearest photo's fix.
+
+const (
+	PhotoVerified         = "verified"
+	PhotoLocationMismatch = "location_mismatch"
+	PhotoTimeMismatch     = "time_mismatch"
+	PhotoLocationOnly     = "location_only"
+	PhotoUnverified       = "unverified"
+)
+
+// photoEvidence — what an upload's EXIF says about where and when it was taken
+type photoEvidence struct {
+	Latitude  *float64   `json:"latitude,omitempty"`
+	Longitude *float64   `json:"longitude,omitempty"`
+	TakenAt   *time.Time `json:"taken_at,omitempty"`
+}
+
+// readPhotoEvidence extracts GPS and capture time from JPEG EXIF. Anything
+// missing or malformed is simply left out.
+func readPhotoEvidence(data []byte, contentType string) *photoEvidence {
+	if contentType != "image/jpeg" {
+		return nil
+	}
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	evidence := &photoEvidence{}
+	if lat, lng, err := x.LatLong(); err == nil && validCoordinates(lat, lng) {
+		evidence.Latitude, evidence.Longitude = &lat, &lng
+	}
+	if takenAt, ok := exifTakenAt(x); ok {
+		evidence.TakenAt = &takenAt
+	}
+	if evidence.Latitude == nil && evidence.TakenAt == nil {
+		return nil
+	}
+	return evidence
+}
+
+func validCoordinates(lat, lng float64) bool {
+	// 0,0 is what many cameras write before they have a fix
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180 && !(lat == 0 && lng == 0)
+}
+
+// exifTakenAt prefers the GPS timestamp (always UTC); DateTimeOriginal has
+// no zone and is read in PHOTO_TIMEZONE.
+func exifTakenAt(x *exif.Exif) (time.Time, bool) {
+	if dateTag, err := x.Get(exif.GPSDateStamp); err == nil {
+		if date, err := dateTag.StringVal(); err == nil {
+			if timeTag, err := x.Get(exif.GPSTimeStamp); err == nil && timeTag.Count == 3 {
+				var hms [3]float64
+				ok := true
+				for i := range hms {
+					num, den, err := timeTag.Rat2(i)
+					if err != nil || den == 0 {
+						ok = false
+						break
+					}
+					hms[i] = float64(num) / float64(den)
+				}
+				day, err := time.Parse("2006:01:02", strings.TrimRight(date, "\x00"))
+				if ok && err == nil {
+					seconds := hms[0]*3600 + hms[1]*60 + hms[2]
+					return day.Add(time.Duration(seconds * float64(time.Second))), true
+				}
+			}
+		}
+	}
+
+	tag, err := x.Get(exif.DateTimeOriginal)
+	if err != nil {
+		if tag, err = x.Get(exif.DateTime); err != nil {
+			return time.Time{}, false
+		}
+	}
+	value, err := tag.StringVal()
+	if err != nil {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(env("PHOTO_TIMEZONE", "Asia/Kolkata"))
+	if err != nil {
+		loc = time.UTC
+	}
+	takenAt, err := time.ParseInLocation("2006:01:02 15:04:05", strings.TrimRight(value, "\x00"), loc)
+	return takenAt, err == nil
+}
+
+// distanceMeters is the haversine distance between two points.
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6371000.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat, dLng := toRad(lat2-lat1), toRad(lng2-lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// mediaPrefixFromURL maps any of our media URLs (original or rendition) to
+// the upload prefix its MediaObject is keyed by.
+func mediaPrefixFromURL(rawURL string) (string, bool) {
+	objectName := strings.TrimPrefix(rawURL, mediaURL(""))
+	if objectName == rawURL || objectName == "" {
+		return "", false
+	}
+	return path.Dir(objectName), true
+}
+
+// complaintMedia loads the MediaObjects behind a complaint's multimedia_urls.
+func complaintMedia(tx *gorm.DB, multimediaURLs string) ([]MediaObject, error) {
+	var urls []string
+	if json.Unmarshal([]byte(multimediaURLs), &urls) != nil || len(urls) == 0 {
+		return nil, nil
+	}
+	prefixes := []string{}
+	for _, u := range urls {
+		if prefix, ok := mediaPrefixFromURL(u); ok {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil, nil
+	}
+	var media []MediaObject
+	err := tx.Where("prefix IN ?", prefixes).Order("id").Find(&media).Error
+	return media, err
+}
+
+// prefillLocationFromPhotos gives a complaint without coordinates the GPS
+// fix of its first geotagged photo.
+func prefillLocationFromPhotos(complaint *Complaint, media []MediaObject) {
+	if complaint.Latitude != 0 || complaint.Longitude != 0 {
+		return
+	}
+	for _, m := range media {
+		if m.ExifLatitude != nil && m.ExifLongitude != nil {
+			complaint.Latitude, complaint.Longitude = *m.ExifLatitude, *m.ExifLongitude
+			complaint.LocationFromPhoto = true
+			return
+		}
+	}
+}
+
+// verifyPhotoEvidence stamps PhotoVerification, PhotoDistanceM and
+// PhotoTakenAt from the photo nearest to the complaint.
+func verifyPhotoEvidence(complaint *Complaint, media []MediaObject, filedAt time.Time) {
+	complaint.PhotoVerification = PhotoUnverified
+	complaint.PhotoDistanceM, complaint.PhotoTakenAt = nil, nil
+
+	var nearest *MediaObject
+	best := math.MaxFloat64
+	for i, m := range media {
+		if m.ExifLatitude == nil || m.ExifLongitude == nil {
+			continue
+		}
+		if d := distanceMeters(complaint.Latitude, complaint.Longitude, *m.ExifLatitude, *m.ExifLongitude); d < best {
+			best, nearest = d, &media[i]
+		}
+	}
+	if nearest == nil {
+		return
+	}
+	distance := math.Round(best*10) / 10
+	complaint.PhotoDistanceM, complaint.PhotoTakenAt = &distance, nearest.ExifTakenAt
+
+	maxAge := envDuration("PHOTO_MAX_AGE", 72*time.Hour)
+	switch {
+	case best > float64(envInt("PHOTO_MAX_DISTANCE_M", 200)):
+		complaint.PhotoVerification = PhotoLocationMismatch
+	case nearest.ExifTakenAt == nil:
+		// An old photo of the right spot proves nothing about now
+		complaint.PhotoVerification = PhotoLocationOnly
+	case nearest.ExifTakenAt.Before(filedAt.Add(-maxAge)) || nearest.ExifTakenAt.After(filedAt.Add(time.Hour)):
+		// An hour of slack for camera clocks running ahead
+		complaint.PhotoVerification = PhotoTimeMismatch
+	default:
+		complaint.PhotoVerification = PhotoVerified
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// geotagged is an upload whose EXIF put it at lat,lng, taken at takenAt.
+func geotagged(lat, lng float64, takenAt *time.Time) MediaObject {
+	return MediaObject{ExifLatitude: &lat, ExifLongitude: &lng, ExifTakenAt: takenAt}
+}
+
+func TestVerifyPhotoEvidence(t *testing.T) {
+	filedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { taken := filedAt.Add(d); return &taken }
+	tests := []struct {
+		name      string
+		media     []MediaObject
+		want      string
+		wantDistM bool
+	}{
+		{name: "no photos", want: PhotoUnverified},
+		{name: "no gps fix", media: []MediaObject{{ExifTakenAt: at(-time.Hour)}}, want: PhotoUnverified},
+		{name: "close and recent", media: []MediaObject{geotagged(12.9705, 77.5900, at(-2*time.Hour))}, want: PhotoVerified, wantDistM: true},
+		{name: "too far", media: []MediaObject{geotagged(12.9800, 77.5900, at(-2*time.Hour))}, want: PhotoLocationMismatch, wantDistM: true},
+		{name: "too old", media: []MediaObject{geotagged(12.9705, 77.5900, at(-96*time.Hour))}, want: PhotoTimeMismatch, wantDistM: true},
+		{name: "camera clock slightly ahead", media: []MediaObject{geotagged(12.9705, 77.5900, at(30*time.Minute))}, want: PhotoVerified, wantDistM: true},
+		{name: "taken after filing", media: []MediaObject{geotagged(12.9705, 77.5900, at(3*time.Hour))}, want: PhotoTimeMismatch, wantDistM: true},
+		{name: "no capture time", media: []MediaObject{geotagged(12.9705, 77.5900, nil)}, want: PhotoLocationOnly, wantDistM: true},
+		{
+			name:      "nearest photo decides",
+			media:     []MediaObject{geotagged(12.9800, 77.5900, at(-time.Hour)), geotagged(12.9701, 77.5900, at(-time.Hour))},
+			want:      PhotoVerified,
+			wantDistM: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			complaint := Complaint{Latitude: 12.9700, Longitude: 77.5900}
+			verifyPhotoEvidence(&complaint, tt.media, filedAt)
+			if complaint.PhotoVerification != tt.want {
+				t.Errorf("photo_verification = %s, want %s", complaint.PhotoVerification, tt.want)
+			}
+			if (complaint.PhotoDistanceM != nil) != tt.wantDistM {
+				t.Errorf("photo_distance_m = %v, want set %v", complaint.PhotoDistanceM, tt.wantDistM)
+			}
+		})
+	}
+}
+
+func TestPrefillLocationFromPhotos(t *testing.T) {
+	tests := []struct {
+		name       string
+		lat, lng   float64
+		media      []MediaObject
+		wantLat    float64
+		wantFilled bool
+	}{
+		{name: "first geotagged photo", media: []MediaObject{{}, geotagged(12.97, 77.59, nil), geotagged(13.1, 77.6, nil)}, wantLat: 12.97, wantFilled: true},
+		{name: "no geotagged photo", media: []MediaObject{{}}, wantLat: 0},
+		{name: "coordinates given", lat: 13.0, lng: 77.5, media: []MediaObject{geotagged(12.97, 77.59, nil)}, wantLat: 13.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			complaint := Complaint{Latitude: tt.lat, Longitude: tt.lng}
+			prefillLocationFromPhotos(&complaint, tt.media)
+			if complaint.Latitude != tt.wantLat || complaint.LocationFromPhoto != tt.wantFilled {
+				t.Errorf("latitude = %v, from photo = %v; want %v, %v", complaint.Latitude, complaint.LocationFromPhoto, tt.wantLat, tt.wantFilled)
+			}
+		})
+	}
+}
+
+func TestMediaPrefixFromURL(t *testing.T) {
+	tests := []struct {
+		url    string
+		want   string
+		wantOK bool
+	}{
+		{mediaURL("complaints/2026/10/abc/original.jpg"), "complaints/2026/10/abc", true},
+		{mediaURL("complaints/2026/10/abc/display.jpg"), "complaints/2026/10/abc", true},
+		{mediaURL(""), "", false},
+		{"https://example.com/complaints/2026/10/abc/original.jpg", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := mediaPrefixFromURL(tt.url)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("mediaPrefixFromURL(%q) = %q, %v; want %q, %v", tt.url, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
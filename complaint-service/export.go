@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// ── Export (CSV / XLSX / GeoJSON) ───────────────────────────────────────────
+//
+// GET /complaints/export?format=csv|xlsx|geojson plus the list filters.
+// Rows are scanned one at a time as the query result streams in and written
+// as they arrive, so a 100k-row export never sits in memory: CSV and GeoJSON
+// go straight to the response, XLSX through excelize's StreamWriter (which
+// spills to a temp file past a few MB). Managers only see their own
+// government. Text cells that a spreadsheet would run as a formula are
+// prefixed with a quote.
+
+type exportRow struct {
+	ID                uint
+	GovernmentID      uint
+	DepartmentID      *uint
+	Ward              string
+	Category          string
+	Status            string
+	Description       string
+	ManualLocation    string
+	Latitude          float64
+	Longitude         float64
+	Upvotes           int
+	Downvotes         int
+	PriorityScore     int
+	CreatedAt         time.Time
+	LatestAction      *string
+	LatestActionAt    *time.Time
+	CompletionPercent int
+	ResolvedAt        *time.Time
+}
+
+var exportHeaders = []string{
+	"id", "government_id", "department_id", "ward", "category", "status", "description",
+	"manual_location", "latitude", "longitude", "upvotes", "downvotes", "priority_score",
+	"created_at", "latest_action", "latest_action_at", "completion_percent",
+	"resolved_at", "resolution_hours",
+}
+
+// values returns the row in exportHeaders order; nil marks an empty cell.
+func (r *exportRow) values() []interface{} {
+	var dept, latestAction, latestActionAt, resolvedAt, resolutionHours interface{}
+	if r.DepartmentID != nil {
+		dept = *r.DepartmentID
+	}
+	if r.LatestAction != nil {
+		latestAction = *r.LatestAction
+	}
+	if r.LatestActionAt != nil {
+		latestActionAt = r.LatestActionAt.UTC().Format(time.RFC3339)
+	}
+	if r.ResolvedAt != nil {
+		resolvedAt = r.ResolvedAt.UTC().Format(time.RFC3339)
+		resolutionHours = float64(int(r.ResolvedAt.Sub(r.CreatedAt).Hours()*10)) / 10
+	}
+	return []interface{}{
+		r.ID, r.GovernmentID, dept, r.Ward, r.Category, r.Status, r.Description,
+		r.ManualLocation, r.Latitude, r.Longitude, r.Upvotes, r.Downvotes, r.PriorityScore,
+		r.CreatedAt.UTC().Format(time.RFC3339), latestAction, latestActionAt, r.CompletionPercent,
+		resolvedAt, resolutionHours,
+	}
+}
+
+// spreadsheetSafe keeps citizen text from being evaluated as a formula.
+func spreadsheetSafe(values []interface{}) []interface{} {
+	for i, v := range values {
+		if text, ok := v.(string); ok && text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+			values[i] = "'" + text
+		}
+	}
+	return values
+}
+
+type exportWriter interface {
+	header() error
+	row(r *exportRow) error
+	flush() // push buffered rows to the client
+	close() error
+}
+
+// ── CSV ──
+
+type csvExport struct {
+	c *gin.Context
+	w *csv.Writer
+}
+
+func (e *csvExport) header() error { return e.w.Write(exportHeaders) }
+
+func (e *csvExport) row(r *exportRow) error {
+	values := spreadsheetSafe(r.values())
+	record := make([]string, len(values))
+	for i, v := range values {
+		if v != nil {
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExport) flush() {
+	e.w.Flush()
+	e.c.Writer.Flush()
+}
+
+func (e *csvExport) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// ── XLSX ──
+
+type xlsxExport struct {
+	c    *gin.Context
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	next int
+}
+
+func newXLSXExport(c *gin.Context) (*xlsxExport, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", "Complaints"); err != nil {
+		return nil, err
+	}
+	sw, err := file.NewStreamWriter("Complaints")
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxExport{c: c, file: file, sw: sw, next: 1}, nil
+}
+
+func (e *xlsxExport) setRow(values []interface{}) error {
+	cell, err := excelize.CoordinatesToCellName(1, e.next)
+	if err != nil {
+		return err
+	}
+	e.next++
+	return e.sw.SetRow(cell, values)
+}
+
+func (e *xlsxExport) header() error {
+	values := make([]interface{}, len(exportHeaders))
+	for i, h := range exportHeaders {
+		values[i] = h
+	}
+	return e.setRow(values)
+}
+
+func (e *xlsxExport) row(r *exportRow) error { return e.setRow(spreadsheetSafe(r.values())) }
+
+// flush is a no-op: the workbook is only written once complete
+func (e *xlsxExport) flush() {}
+
+func (e *xlsxExport) close() error {
+	if err := e.sw.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.c.Writer)
+}
+
+// ── GeoJSON ──
+
+type geoJSONExport struct {
+	c     *gin.Context
+	first bool
+}
+
+func (e *geoJSONExport) header() error {
+	e.first = true
+	_, err := e.c.Writer.WriteString(`{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+func (e *geoJSONExport) row(r *exportRow) error {
+	values := r.values()
+	props := make(map[string]interface{}, len(values))
+	for i, v := range values {
+		props[exportHeaders[i]] = v
+	}
+	feature, err := json.Marshal(map[string]interface{}{
+		"type":       "Feature",
+		"geometry":   map[string]interface{}{"type": "Point", "coordinates": []float64{r.Longitude, r.Latitude}},
+		"properties": props,
+	})
+	if err != nil {
+		return err
+	}
+	if !e.first {
+		e.c.Writer.WriteString(",")
+	}
+	e.first = false
+	_, err = e.c.Writer.Write(feature)
+	return err
+}
+
+func (e *geoJSONExport) flush() { e.c.Writer.Flush() }
+
+func (e *geoJSONExport) close() error {
+	_, err := e.c.Writer.WriteString("]}")
+	return err
+}
+
+// ── Export Handler ──────────────────────────────────────────────────────────
+
+func exportComplaintsHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	var (
+		out         exportWriter
+		contentType string
+	)
+	switch format {
+	case "csv":
+		out, contentType = &csvExport{c: c, w: csv.NewWriter(c.Writer)}, "text/csv; charset=utf-8"
+	case "xlsx":
+		xlsx, err := newXLSXExport(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer xlsx.file.Close() // removes the StreamWriter's temp file
+		out, contentType = xlsx, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case "geojson":
+		out, contentType = &geoJSONExport{c: c}, "application/geo+json"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, xlsx or geojson"})
+		return
+	}
+
+	query, err := applyComplaintFilters(c, db.Model(&Complaint{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if getAdminRole(c) != "super_admin" {
+		query = query.Where("government_id = ?", getGovID(c))
+	}
+
+	rows, err := db.Raw(`
+		SELECT c.id, c.government_id, c.department_id, c.ward, c.category, c.status, c.description,
+			c.manual_location, c.latitude, c.longitude, c.upvotes, c.downvotes,
+			c.upvotes - c.downvotes * 2 AS priority_score, c.created_at,
+			la.action_details AS latest_action, la.created_at AS latest_action_at,
+			COALESCE(la.completion_percent, 0) AS completion_percent, r.resolved_at
+		FROM (?) AS c
+		LEFT JOIN LATERAL (
+			SELECT action_details, completion_percent, created_at FROM action_takens
+			WHERE complaint_id = c.id ORDER BY created_at DESC, id DESC LIMIT 1
+		) la ON true
+		LEFT JOIN LATERAL (
+			SELECT MAX(created_at) AS resolved_at FROM complaint_status_histories
+			WHERE complaint_id = c.id AND to_status = ?
+		) r ON true
+		ORDER BY c.created_at, c.id`, query, StatusResolved).Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("complaints-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	// Headers are sent; from here on failures can only truncate the body
+	if err := out.header(); err != nil {
+		log.Printf("[complaint-service] Export aborted: %v", err)
+		return
+	}
+	count := 0
+	for rows.Next() {
+		var row exportRow
+		if err := db.ScanRows(rows, &row); err != nil {
+			log.Printf("[complaint-service] Export aborted after %d rows: %v", count, err)
+			return
+		}
+		if err := out.row(&row); err != nil {
+			log.Printf("[complaint-service] Export aborted after %d rows: %v", count, err)
+			return
+		}
+		count++
+		if count%1000 == 0 {
+			out.flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("[complaint-service] Export aborted after %d rows: %v", count, err)
+		return
+	}
+	if err := out.close(); err != nil {
+		log.Printf("[complaint-service] Export failed: %v", err)
+		return
+	}
+	log.Printf("[complaint-service] Exported %d complaints as %s", count, format)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestSpreadsheetSafe(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want interface{}
+	}{
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+91 98765 43210", "'+91 98765 43210"},
+		{"-5 degrees", "'-5 degrees"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tindented", "'\tindented"},
+		{"Pothole = danger", "Pothole = danger"},
+		{"", ""},
+		{-5, -5},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := spreadsheetSafe([]interface{}{tt.in})[0]; got != tt.want {
+			t.Errorf("spreadsheetSafe(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExportRowValues(t *testing.T) {
+	created := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	resolved := created.Add(26*time.Hour + 20*time.Minute)
+	dept := uint(4)
+
+	open := (&exportRow{ID: 1, CreatedAt: created}).values()
+	if len(open) != len(exportHeaders) {
+		t.Fatalf("%d values for %d headers", len(open), len(exportHeaders))
+	}
+	for _, i := range []int{2, 14, 15, 17, 18} { // department, latest action, resolution
+		if open[i] != nil {
+			t.Errorf("%s = %v, want empty", exportHeaders[i], open[i])
+		}
+	}
+
+	done := (&exportRow{ID: 1, DepartmentID: &dept, CreatedAt: created, ResolvedAt: &resolved}).values()
+	if done[2] != uint(4) || done[17] != "2026-10-02T10:20:00Z" || done[18] != 26.3 {
+		t.Errorf("department %v, resolved_at %v, resolution_hours %v", done[2], done[17], done[18])
+	}
+}
+
+func TestExportComplaintsHandler(t *testing.T) {
+	manager := map[string]interface{}{"admin_id": float64(3), "admin_role": "manager", "government_id": float64(2)}
+	tests := []struct {
+		name       string
+		claims     map[string]interface{}
+		format     string
+		wantCode   int
+		wantScoped bool
+	}{
+		{name: "csv", claims: manager, format: "csv", wantCode: http.StatusOK, wantScoped: true},
+		{name: "geojson", claims: superAdminClaims, format: "geojson", wantCode: http.StatusOK},
+		{name: "xlsx", claims: manager, format: "xlsx", wantCode: http.StatusOK, wantScoped: true},
+		{name: "unknown format", claims: manager, format: "pdf", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			created := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+			stub.returns("LEFT JOIN LATERAL",
+				[]string{"id", "government_id", "category", "description", "latitude", "longitude", "created_at"},
+				[]driver.Value{int64(1), int64(2), "Roads", "=cmd|' /C calc'!A0", 12.97, 77.59, created},
+				[]driver.Value{int64(2), int64(2), "Water", "No water since Monday", 12.98, 77.6, created})
+			c, w := newTestContext(http.MethodGet, "/complaints/export?format="+tt.format, "", tt.claims)
+
+			exportComplaintsHandler(c)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			if scoped := stub.ran("government_id = "); scoped != tt.wantScoped {
+				t.Errorf("scoped to the caller's government = %v, want %v", scoped, tt.wantScoped)
+			}
+
+			var rows [][]string
+			switch tt.format {
+			case "csv":
+				var err error
+				if rows, err = csv.NewReader(w.Body).ReadAll(); err != nil {
+					t.Fatal(err)
+				}
+			case "xlsx":
+				file, err := excelize.OpenReader(bytes.NewReader(w.Body.Bytes()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if rows, err = file.GetRows("Complaints"); err != nil {
+					t.Fatal(err)
+				}
+			case "geojson":
+				var collection struct {
+					Features []struct {
+						Geometry   struct{ Coordinates []float64 }
+						Properties map[string]interface{}
+					}
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &collection); err != nil {
+					t.Fatalf("invalid GeoJSON: %v: %s", err, w.Body)
+				}
+				if len(collection.Features) != 2 || collection.Features[0].Geometry.Coordinates[0] != 77.59 {
+					t.Fatalf("features = %+v", collection.Features)
+				}
+				// GeoJSON is not opened by spreadsheets, so text stays as filed
+				if got := collection.Features[0].Properties["description"]; got != "=cmd|' /C calc'!A0" {
+					t.Errorf("description = %v", got)
+				}
+				return
+			}
+			if len(rows) != 3 || rows[0][0] != "id" {
+				t.Fatalf("rows = %v", rows)
+			}
+			if rows[1][6] != "'=cmd|' /C calc'!A0" || rows[2][6] != "No water since Monday" {
+				t.Errorf("descriptions = %q, %q", rows[1][6], rows[2][6])
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ── List Filters + Keyset Pagination ────────────────────────────────────────
+//
+// Shared by GET /complaints and every other endpoint that promises "filter
+// parity" with it (map, export). Query params:
+//   government_id | government_ids=1,2,3   status   department_id   ward_id   category
+//   user_id   created_from / created_to (RFC3339 or YYYY-MM-DD, inclusive)
+//   bbox=minLng,minLat,maxLng,maxLat   has_media=true|false
+//   sla=breached|due_soon|on_track   photo_verification=verified|...
+//   reused_image=true|false   moderation_status (staff only)   q=free text
+
+const priorityOrder = "(upvotes - downvotes * 2) DESC, created_at DESC, id DESC"
+
+func parseFilterTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (use RFC3339 or YYYY-MM-DD)", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+func parseBBox(value string) ([4]float64, error) {
+	var box [4]float64
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return box, fmt.Errorf("bbox must be minLng,minLat,maxLng,maxLat")
+	}
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return box, fmt.Errorf("bbox must be minLng,minLat,maxLng,maxLat")
+		}
+		box[i] = v
+	}
+	if box[0] > box[2] || box[1] > box[3] {
+		return box, fmt.Errorf("bbox min values must not exceed max values")
+	}
+	return box, nil
+}
+
+func splitIDs(value string) []string {
+	ids := []string{}
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// applyComplaintFilters narrows a complaints query from the request's query
+// string. Merged complaints are hidden unless status=merged is requested.
+func applyComplaintFilters(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	query, err := applyFieldFilters(c, query)
+	if err != nil {
+		return nil, err
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q) + "%"
+		query = query.Where("description ILIKE ? OR manual_location ILIKE ? OR category ILIKE ?", like, like, like)
+	}
+	return query, nil
+}
+
+// applyFieldFilters is applyComplaintFilters without the q substring match;
+// GET /complaints/search interprets q as a full-text query instead.
+func applyFieldFilters(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	if govID := c.Query("government_id"); govID != "" {
+		query = query.Where("government_id = ?", govID)
+	} else if ids := splitIDs(c.Query("government_ids")); len(ids) > 0 {
+		query = query.Where("government_id IN ?", ids)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status <> ?", StatusMerged)
+	}
+	if deptID := c.Query("department_id"); deptID != "" {
+		query = query.Where("department_id = ?", deptID)
+	}
+	if wardID := c.Query("ward_id"); wardID != "" {
+		query = query.Where("ward_id = ?", wardID)
+	}
+	// Held and hidden complaints are for their author and the staff of
+	// their government
+	switch {
+	case getAdminRole(c) == "super_admin":
+	case isAdmin(c):
+		query = query.Where("(moderation_status = ? OR government_id = ?)", ModerationVisible, getGovID(c))
+	case getUserID(c) != 0:
+		query = query.Where("(moderation_status = ? OR user_id = ?)", ModerationVisible, getUserID(c))
+	default:
+		query = query.Where("moderation_status = ?", ModerationVisible)
+	}
+	if moderation := c.Query("moderation_status"); moderation != "" && isAdmin(c) {
+		query = query.Where("moderation_status = ?", moderation)
+	}
+	if verification := c.Query("photo_verification"); verification != "" {
+		query = query.Where("photo_verification = ?", verification)
+	}
+	switch c.Query("reused_image") {
+	case "":
+	case "true":
+		query = query.Where("reused_image")
+	case "false":
+		query = query.Where("NOT reused_image")
+	default:
+		return nil, fmt.Errorf("reused_image must be true or false")
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("LOWER(category) = LOWER(?)", category)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if from := c.Query("created_from"); from != "" {
+		t, err := parseFilterTime(from, false)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("created_at >= ?", t)
+	}
+	if to := c.Query("created_to"); to != "" {
+		t, err := parseFilterTime(to, true)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("created_at <= ?", t)
+	}
+	if bbox := c.Query("bbox"); bbox != "" {
+		box, err := parseBBox(bbox)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("longitude BETWEEN ? AND ? AND latitude BETWEEN ? AND ?", box[0], box[2], box[1], box[3])
+	}
+	switch c.Query("has_media") {
+	case "":
+	case "true":
+		query = query.Where("COALESCE(multimedia_urls, '') NOT IN ('', '[]', 'null')")
+	case "false":
+		query = query.Where("COALESCE(multimedia_urls, '') IN ('', '[]', 'null')")
+	default:
+		return nil, fmt.Errorf("has_media must be true or false")
+	}
+	return applySLAFilter(query, c.Query("sla"))
+}
+
+// listCursor is the opaque keyset position in priority order.
+type listCursor struct {
+	Score     int       `json:"s"`
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"i"`
+}
+
+func encodeCursor(complaint *Complaint) string {
+	raw, _ := json.Marshal(listCursor{Score: complaint.PriorityScore(), CreatedAt: complaint.CreatedAt, ID: complaint.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (*listCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// applyCursor continues after the cursor in priorityOrder. Priority scores
+// move as votes arrive, so pages are stable per request, not across time.
+func applyCursor(query *gorm.DB, cursor *listCursor) *gorm.DB {
+	if cursor == nil {
+		return query
+	}
+	return query.Where("((upvotes - downvotes * 2), created_at, id) < (?, ?, ?)",
+		cursor.Score, cursor.CreatedAt, cursor.ID)
+}
+
+func pageLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		return 50
+	}
+	if limit > 200 {
+		return 200
+	}
+	return limit
+}
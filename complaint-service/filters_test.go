@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	complaint := Complaint{ID: 42, Upvotes: 10, Downvotes: 3, CreatedAt: created}
+	encoded := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name    string
+		value   string
+		want    listCursor
+		wantErr bool
+	}{
+		{name: "round trip", value: encodeCursor(&complaint), want: listCursor{Score: 4, CreatedAt: created, ID: 42}},
+		{name: "not base64", value: "not a cursor!", wantErr: true},
+		{name: "padded base64", value: base64.URLEncoding.EncodeToString([]byte(`{"i":1}`)), wantErr: true},
+		{name: "not json", value: encoded("42"), wantErr: true},
+		{name: "wrong types", value: encoded(`{"s":"high","i":1}`), wantErr: true},
+		{name: "missing id", value: encoded(`{"s":4,"t":"2026-03-14T09:30:00Z"}`), wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeCursor(%q) = %+v, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCursor(%q): %v", tt.value, err)
+			}
+			if got.Score != tt.want.Score || got.ID != tt.want.ID || !got.CreatedAt.Equal(tt.want.CreatedAt) {
+				t.Errorf("decodeCursor(%q) = %+v, want %+v", tt.value, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterTime(t *testing.T) {
+	tests := []struct {
+		value    string
+		endOfDay bool
+		want     time.Time
+		wantErr  bool
+	}{
+		{value: "2026-03-14T09:30:00Z", want: time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)},
+		{value: "2026-03-14T09:30:00Z", endOfDay: true, want: time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)},
+		{value: "2026-03-14", want: time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{value: "2026-03-14", endOfDay: true, want: time.Date(2026, 3, 14, 23, 59, 59, 999999999, time.UTC)},
+		{value: "14/03/2026", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseFilterTime(tt.value, tt.endOfDay)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFilterTime(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseFilterTime(%q, %v) = %v, want %v", tt.value, tt.endOfDay, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ── Map Endpoints (GeoJSON + Mapbox Vector Tiles) ───────────────────────────
+//
+// Both accept the same filters as GET /complaints (see filters.go). The heavy
+// lifting happens in PostGIS so the service never holds the full result set
+// as Go structs.
+
+// pointGeomSQL is the complaint location as a WGS84 geometry. Queries use
+// this exact expression so idx_complaint_geom can serve them.
+const pointGeomSQL = "ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)"
+
+// mapColumns are the per-feature properties exposed to map clients.
+const mapColumns = "id, status, category, government_id, department_id, upvotes, downvotes, " +
+	"(upvotes - downvotes * 2) AS priority_score, created_at, latitude, longitude"
+
+func geoJSONMaxFeatures() int {
+	n, err := strconv.Atoi(env("GEOJSON_MAX_FEATURES", "50000"))
+	if err != nil || n <= 0 {
+		return 50000
+	}
+	return n
+}
+
+// GET /complaints.geojson — FeatureCollection of filtered complaints
+func complaintsGeoJSONHandler(c *gin.Context) {
+	query, err := applyComplaintFilters(c, db.Model(&Complaint{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	features := query.Select(mapColumns + ", " + pointGeomSQL + " AS geom").
+		Order(priorityOrder).Limit(geoJSONMaxFeatures())
+
+	var collection string
+	err = db.Raw(`
+		SELECT json_build_object(
+			'type', 'FeatureCollection',
+			'features', COALESCE(json_agg(ST_AsGeoJSON(t.*, 'geom', 6)::json), '[]'::json)
+		)::text
+		FROM (?) AS t`, features).Row().Scan(&collection)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/geo+json", []byte(collection))
+}
+
+// parseTileCoords validates z/x/y from the path (y may carry a .mvt suffix).
+func parseTileCoords(c *gin.Context) (z, x, y int, ok bool) {
+	var err error
+	if z, err = strconv.Atoi(c.Param("z")); err != nil || z < 0 || z > 22 {
+		return 0, 0, 0, false
+	}
+	max := 1 << z
+	if x, err = strconv.Atoi(c.Param("x")); err != nil || x < 0 || x >= max {
+		return 0, 0, 0, false
+	}
+	if y, err = strconv.Atoi(strings.TrimSuffix(c.Param("y"), ".mvt")); err != nil || y < 0 || y >= max {
+		return 0, 0, 0, false
+	}
+	return z, x, y, true
+}
+
+// GET /complaints/tiles/:z/:x/:y.mvt — vector tile with layer "complaints"
+func complaintTileHandler(c *gin.Context) {
+	z, x, y, ok := parseTileCoords(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tile coordinates"})
+		return
+	}
+	query, err := applyComplaintFilters(c, db.Model(&Complaint{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filtered := query.Session(&gorm.Session{}).
+		Select(mapColumns+", "+pointGeomSQL+" AS geom").
+		Where(pointGeomSQL+" && ST_Transform(ST_TileEnvelope(?, ?, ?), 4326)", z, x, y)
+
+	var tile []byte
+	err = db.Raw(`
+		WITH bounds AS (SELECT ST_TileEnvelope(?, ?, ?) AS env),
+		features AS (
+			SELECT ST_AsMVTGeom(ST_Transform(t.geom, 3857), bounds.env, 4096, 64, true) AS geom,
+				t.id, t.status, t.category, t.government_id, t.department_id,
+				t.upvotes, t.downvotes, t.priority_score,
+				EXTRACT(EPOCH FROM t.created_at)::bigint AS created_at
+			FROM (?) AS t, bounds
+		)
+		SELECT COALESCE(ST_AsMVT(features.*, 'complaints', 4096, 'geom'), '')
+		FROM features`, z, x, y, filtered).Row().Scan(&tile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=60")
+	c.Data(http.StatusOK, "application/vnd.mapbox-vector-tile", tile)
+}
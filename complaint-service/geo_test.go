@@ -0,0 +1,97 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseTileCoords(t *testing.T) {
+	tests := []struct {
+		z, x, y string
+		wantOK  bool
+	}{
+		{"0", "0", "0", true},
+		{"0", "0", "0.mvt", true},
+		{"12", "2925", "1873.mvt", true},
+		{"1", "2", "0", false}, // only 0..1 at zoom 1
+		{"1", "1", "2", false},
+		{"-1", "0", "0", false},
+		{"23", "0", "0", false},
+		{"z", "0", "0", false},
+		{"3", "0", "0.png", false},
+	}
+	for _, tt := range tests {
+		c, _ := newTestContext(http.MethodGet, "/", "", nil)
+		c.Params = gin.Params{{Key: "z", Value: tt.z}, {Key: "x", Value: tt.x}, {Key: "y", Value: tt.y}}
+		if _, _, _, ok := parseTileCoords(c); ok != tt.wantOK {
+			t.Errorf("parseTileCoords(%s/%s/%s) ok = %v, want %v", tt.z, tt.x, tt.y, ok, tt.wantOK)
+		}
+	}
+}
+
+func TestComplaintsGeoJSONHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+	}{
+		{name: "filtered", query: "?status=pending&bbox=77.5,12.9,77.7,13.1", wantCode: http.StatusOK},
+		{name: "bad bbox", query: "?bbox=77.5,12.9", wantCode: http.StatusBadRequest},
+		{name: "bad sla filter", query: "?sla=late", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.returns("'FeatureCollection'", []string{"json_build_object"}, []driver.Value{`{"type":"FeatureCollection","features":[]}`})
+			c, w := newTestContext(http.MethodGet, "/complaints.geojson"+tt.query, "", nil)
+
+			complaintsGeoJSONHandler(c)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/geo+json" {
+				t.Errorf("content type = %q", ct)
+			}
+			if w.Body.String() != `{"type":"FeatureCollection","features":[]}` {
+				t.Errorf("body = %s", w.Body)
+			}
+			// Held and hidden complaints stay off public maps
+			if !stub.ran("moderation_status = ") {
+				t.Error("moderation filter not applied")
+			}
+		})
+	}
+}
+
+func TestComplaintTileHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		y        string
+		wantCode int
+	}{
+		{name: "tile", y: "1873.mvt", wantCode: http.StatusOK},
+		{name: "outside the grid", y: "4096.mvt", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.returns("ST_AsMVT(", []string{"st_asmvt"}, []driver.Value{[]byte{0x1a, 0x02}})
+			c, w := newTestContext(http.MethodGet, "/complaints/tiles/12/2925/"+tt.y, "", nil)
+			c.Params = gin.Params{{Key: "z", Value: "12"}, {Key: "x", Value: "2925"}, {Key: "y", Value: tt.y}}
+
+			complaintTileHandler(c)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantCode == http.StatusOK && (w.Header().Get("Content-Type") != "application/vnd.mapbox-vector-tile" || w.Body.Len() != 2) {
+				t.Errorf("tile = %q %v", w.Header().Get("Content-Type"), w.Body.Bytes())
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ── Image Reuse Detection (perceptual hash) ─────────────────────────────────
+//
+// Every upload gets a 64-bit difference hash (dHash) on its MediaObject.
+// Re-encoding, resizing and light edits move only a few bits, so two images
+// within PHASH_MAX_DISTANCE (6) bits of each other are treated as the same
+// picture. Matches are reported in the upload response, and when a complaint
+// is filed (or its photos change) they are recorded as ImageMatch rows
+// linking it to the earlier complaint and the complaint is flagged
+// reused_image. Staff review them at GET /complaints/image-reuse.
+// Only the uploader can attach a photo, so nobody borrows another user's
+// picture (or its EXIF) as their own evidence.
+
+var errForeignMedia = errors.New("photo was not uploaded by you")
+
+// ImageMatch — a complaint photo near-identical to an earlier complaint's
+type ImageMatch struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	GovernmentID       uint      `gorm:"index;not null" json:"government_id"` // of ComplaintID
+	ComplaintID        uint      `gorm:"uniqueIndex:idx_image_match;not null" json:"complaint_id"`
+	MediaID            uint      `gorm:"uniqueIndex:idx_image_match;not null" json:"media_id"`
+	MatchedComplaintID uint      `gorm:"index;not null" json:"matched_complaint_id"`
+	MatchedMediaID     uint      `gorm:"uniqueIndex:idx_image_match;not null" json:"matched_media_id"`
+	Distance           int       `json:"distance"` // differing hash bits, 0 = same picture
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// similarImage — an existing complaint photo close to a given hash
+type similarImage struct {
+	MediaID     uint   `json:"media_id"`
+	ComplaintID uint   `json:"complaint_id"`
+	ObjectName  string `json:"-"`
+	ImageURL    string `json:"image_url"`
+	Distance    int    `json:"distance"`
+}
+
+// perceptualHash computes a dHash: the image is shrunk to 9x8 grey pixels
+// and each bit records whether a pixel is brighter than its right neighbour.
+// Flat images carry no signal and return nil.
+func perceptualHash(img image.Image) *int64 {
+	small := imaging.Resize(imaging.Grayscale(img), 9, 8, imaging.Box)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			left := small.Pix[small.PixOffset(x, y)]
+			right := small.Pix[small.PixOffset(x+1, y)]
+			hash <<= 1
+			if left > right {
+				hash |= 1
+			}
+		}
+	}
+	if hash == 0 || hash == ^uint64(0) {
+		return nil
+	}
+	signed := int64(hash) // stored as bigint; only the bits matter
+	return &signed
+}
+
+// findSimilarImages lists complaint photos within PHASH_MAX_DISTANCE of hash,
+// skipping those attached to excludeComplaintID.
+func findSimilarImages(tx *gorm.DB, hash int64, excludeComplaintID uint) ([]similarImage, error) {
+	matches := []similarImage{}
+	err := tx.Raw(`
+		SELECT id AS media_id, complaint_id, object_name, distance FROM (
+			SELECT id, complaint_id, object_name, bit_count(int8send(phash # @hash)) AS distance
+			FROM media_objects
+			WHERE kind = 'complaints' AND phash IS NOT NULL
+				AND complaint_id IS NOT NULL AND complaint_id <> @exclude
+		) m
+		WHERE distance <= @max
+		ORDER BY distance, id
+		LIMIT 20`,
+		map[string]interface{}{"hash": hash, "exclude": excludeComplaintID, "max": envInt("PHASH_MAX_DISTANCE", 6)},
+	).Scan(&matches).Error
+	for i := range matches {
+		matches[i].ImageURL = mediaURL(matches[i].ObjectName)
+	}
+	return matches, err
+}
+
+// checkMediaOwnership refuses photos the caller did not upload, unless they
+// are already attached to this complaint (complaintID 0 for a new one).
+func checkMediaOwnership(c *gin.Context, media []MediaObject, complaintID uint) error {
+	ownerType, ownerID := uploadOwner(c)
+	for _, m := range media {
+		if complaintID != 0 && m.ComplaintID != nil && *m.ComplaintID == complaintID {
+			continue
+		}
+		if m.OwnerType != ownerType || m.OwnerID != ownerID {
+			return fmt.Errorf("%w: %s", errForeignMedia, mediaURL(m.ObjectName))
+		}
+	}
+	return nil
+}
+
+// linkComplaintMedia attaches a complaint's photos to it, records matches
+// against other complaints' photos and sets complaint.ReusedImage.
+func linkComplaintMedia(tx *gorm.DB, complaint *Complaint, media []MediaObject) error {
+	ids := []uint{0}
+	for _, m := range media {
+		ids = append(ids, m.ID)
+	}
+	// Photos dropped from the complaint are released; a photo already
+	// attached elsewhere stays there and shows up below as a 0-bit match
+	if err := tx.Model(&MediaObject{}).Where("complaint_id = ? AND id NOT IN ?", complaint.ID, ids).
+		Update("complaint_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&MediaObject{}).Where("id IN ? AND complaint_id IS NULL", ids).
+		Update("complaint_id", complaint.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("complaint_id = ?", complaint.ID).Delete(&ImageMatch{}).Error; err != nil {
+		return err
+	}
+
+	reused := false
+	for _, m := range media {
+		if m.PHash == nil {
+			continue
+		}
+		similar, err := findSimilarImages(tx, *m.PHash, complaint.ID)
+		if err != nil {
+			return err
+		}
+		for _, s := range similar {
+			match := ImageMatch{
+				GovernmentID:       complaint.GovernmentID,
+				ComplaintID:        complaint.ID,
+				MediaID:            m.ID,
+				MatchedComplaintID: s.ComplaintID,
+				MatchedMediaID:     s.MediaID,
+				Distance:           s.Distance,
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&match).Error; err != nil {
+				return err
+			}
+			reused = true
+		}
+	}
+	complaint.ReusedImage = reused
+	return tx.Model(complaint).UpdateColumn("reused_image", reused).Error
+}
+
+// GET /complaints/image-reuse?government_id=&limit=&offset=
+func listImageReuseHandler(c *gin.Context) {
+	govID := getGovID(c)
+	if getAdminRole(c) == "super_admin" {
+		id, _ := strconv.Atoi(c.Query("government_id"))
+		govID = uint(id)
+	}
+	limit := pageLimit(c)
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	type reuseRow struct {
+		ImageMatch
+		ObjectName        string `json:"-"`
+		MatchedObjectName string `json:"-"`
+		ImageURL          string `json:"image_url"`
+		MatchedImageURL   string `json:"matched_image_url"`
+		Status            string `json:"status"`
+		MatchedStatus     string `json:"matched_status"`
+		Total             int64  `json:"-"`
+	}
+	rows := []reuseRow{}
+	err := db.Raw(`
+		SELECT im.*, a.object_name, b.object_name AS matched_object_name,
+			c.status, mc.status AS matched_status, COUNT(*) OVER() AS total
+		FROM image_matches im
+		JOIN media_objects a ON a.id = im.media_id
+		JOIN media_objects b ON b.id = im.matched_media_id
+		JOIN complaints c ON c.id = im.complaint_id
+		JOIN complaints mc ON mc.id = im.matched_complaint_id
+		WHERE (@gov = 0 OR im.government_id = @gov)
+		ORDER BY im.created_at DESC, im.id DESC
+		LIMIT @limit OFFSET @offset`,
+		map[string]interface{}{"gov": govID, "limit": limit, "offset": offset},
+	).Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var total int64
+	for i := range rows {
+		rows[i].ImageURL = mediaURL(rows[i].ObjectName)
+		rows[i].MatchedImageURL = mediaURL(rows[i].MatchedObjectName)
+		total = rows[i].Total
+	}
+	c.JSON(http.StatusOK, gin.H{"matches": rows, "total": total, "limit": limit, "offset": offset})
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"math/bits"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/gin-gonic/gin"
+)
+
+// testScene draws a smooth, structured picture (no flat regions) so its
+// hash is stable under re-encoding and resizing.
+func testScene(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			v := 128 + 60*math.Sin(7*fx+2*fy) + 50*math.Cos(5*fy-3*fx*fx)
+			img.Set(x, y, color.NRGBA{uint8(v), uint8(v * 0.8), uint8(255 - v), 255})
+		}
+	}
+	return img
+}
+
+func reencodeJPEG(t *testing.T, img image.Image, quality int) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestPerceptualHash(t *testing.T) {
+	scene := testScene(640, 480)
+	original := perceptualHash(scene)
+	if original == nil {
+		t.Fatal("structured image has no hash")
+	}
+
+	tests := []struct {
+		name        string
+		img         image.Image
+		maxDistance int // inclusive; PHASH_MAX_DISTANCE defaults to 6
+		minDistance int
+	}{
+		{name: "identical", img: testScene(640, 480), maxDistance: 0},
+		{name: "jpeg re-encode", img: reencodeJPEG(t, scene, 60), maxDistance: 6},
+		{name: "downscaled", img: imaging.Resize(scene, 160, 120, imaging.Lanczos), maxDistance: 6},
+		{name: "brightened", img: imaging.AdjustBrightness(scene, 10), maxDistance: 6},
+		{name: "mirrored", img: imaging.FlipH(scene), minDistance: 12, maxDistance: 64},
+		{name: "different picture", img: imaging.Rotate90(testScene(480, 640)), minDistance: 20, maxDistance: 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := perceptualHash(tt.img)
+			if hash == nil {
+				t.Fatal("no hash")
+			}
+			distance := bits.OnesCount64(uint64(*hash ^ *original))
+			if distance < tt.minDistance || distance > tt.maxDistance {
+				t.Errorf("distance = %d, want %d..%d", distance, tt.minDistance, tt.maxDistance)
+			}
+		})
+	}
+}
+
+func TestPerceptualHashFlatImage(t *testing.T) {
+	for _, c := range []color.Color{color.White, color.Black, color.NRGBA{90, 140, 200, 255}} {
+		if hash := perceptualHash(imaging.New(320, 240, c)); hash != nil {
+			t.Errorf("flat %v image hashed to %x, want nil", c, *hash)
+		}
+	}
+}
+
+func TestCheckMediaOwnership(t *testing.T) {
+	attachedTo := func(id uint) *uint { return &id }
+	tests := []struct {
+		name        string
+		claims      map[string]interface{}
+		media       []MediaObject
+		complaintID uint
+		wantErr     bool
+	}{
+		{
+			name:   "own uploads",
+			claims: map[string]interface{}{"user_id": float64(5)},
+			media:  []MediaObject{{OwnerType: "citizen", OwnerID: 5}, {OwnerType: "citizen", OwnerID: 5}},
+		},
+		{
+			name:    "another citizen's upload",
+			claims:  map[string]interface{}{"user_id": float64(5)},
+			media:   []MediaObject{{OwnerType: "citizen", OwnerID: 5}, {OwnerType: "citizen", OwnerID: 6}},
+			wantErr: true,
+		},
+		{
+			name:    "admin with a citizen's id",
+			claims:  map[string]interface{}{"admin_id": float64(5)},
+			media:   []MediaObject{{OwnerType: "citizen", OwnerID: 5}},
+			wantErr: true,
+		},
+		{
+			name:        "already on this complaint",
+			claims:      map[string]interface{}{"admin_id": float64(2)},
+			media:       []MediaObject{{OwnerType: "citizen", OwnerID: 5, ComplaintID: attachedTo(11)}},
+			complaintID: 11,
+		},
+		{
+			name:        "attached to another complaint",
+			claims:      map[string]interface{}{"user_id": float64(5)},
+			media:       []MediaObject{{OwnerType: "citizen", OwnerID: 6, ComplaintID: attachedTo(12)}},
+			complaintID: 11,
+			wantErr:     true,
+		},
+		{
+			name:    "anonymous",
+			media:   []MediaObject{{OwnerType: "citizen", OwnerID: 5}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			for k, v := range tt.claims {
+				c.Set(k, v)
+			}
+			err := checkMediaOwnership(c, tt.media, tt.complaintID)
+			if tt.wantErr != errors.Is(err, errForeignMedia) {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
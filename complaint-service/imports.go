@@ -0,0 +1,784 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+)
+
+// ── Bulk CSV Import ─────────────────────────────────────────────────────────
+//
+// POST /complaints/imports stores the CSV in MinIO and starts a job that
+// validates every row and writes valid ones in batches. Each batch commits
+// its complaints, row errors and the job checkpoint (processed_rows) in one
+// transaction, so a job interrupted by a crash or a DB outage resumes from
+// the last committed row without duplicating anything. Stale running jobs
+// are picked up again by runImportResumer; failed ones via .../resume.
+//
+// Imported complaints have no citizen (user_id 0), keep their legacy
+// created_at and skip SLA stamping and AI analysis. A dry run validates and
+// reports row errors without writing complaints; it can be committed once.
+
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+type ImportJob struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	GovernmentID  uint            `gorm:"index;not null" json:"government_id"`
+	AdminID       uint            `gorm:"not null" json:"admin_id"`
+	Filename      string          `json:"filename"`
+	ObjectName    string          `gorm:"not null" json:"-"`
+	Mapping       json.RawMessage `gorm:"type:jsonb;not null" json:"mapping"`
+	DryRun        bool            `json:"dry_run"`
+	CommittedAs   *uint           `json:"committed_as,omitempty"` // dry run → the job that committed it
+	Status        string          `gorm:"index;not null" json:"status"`
+	ProcessedRows int             `gorm:"not null;default:0" json:"processed_rows"` // resume checkpoint
+	ImportedRows  int             `gorm:"not null;default:0" json:"imported_rows"`  // valid rows on a dry run
+	FailedRows    int             `gorm:"not null;default:0" json:"failed_rows"`    // rows, not errors
+	LastError     string          `gorm:"type:text" json:"last_error,omitempty"`
+	HeartbeatAt   *time.Time      `json:"heartbeat_at,omitempty"`
+	FinishedAt    *time.Time      `json:"finished_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// ImportRowError — why one CSV row was rejected (row 1 = first data row)
+type ImportRowError struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	JobID     uint      `gorm:"index;not null" json:"job_id"`
+	RowNumber int       `gorm:"not null" json:"row"`
+	Column    string    `json:"column,omitempty"`
+	Message   string    `gorm:"type:text;not null" json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// importMapping is the job's column mapping and validation options.
+//
+// Columns maps target fields to CSV headers. Targets: description, category,
+// latitude, longitude (required), manual_location, status, created_at,
+// resolved_at, department_id, external_ref.
+type importMapping struct {
+	Columns       map[string]string `json:"columns"`
+	DateFormat    string            `json:"date_format,omitempty"`    // Go layout; common formats are tried otherwise
+	DefaultStatus string            `json:"default_status,omitempty"` // when status is unmapped or blank
+	StatusMap     map[string]string `json:"status_map,omitempty"`     // legacy value → lifecycle status
+	Categories    []string          `json:"categories,omitempty"`     // allowed categories (case-insensitive)
+}
+
+var importTargets = map[string]bool{
+	"description": true, "category": true, "latitude": true, "longitude": true,
+	"manual_location": true, "status": true, "created_at": true, "resolved_at": true,
+	"department_id": true, "external_ref": true,
+}
+
+var errDryRunCommitted = errors.New("this dry run has already been committed")
+
+var importRequired = []string{"description", "category", "latitude", "longitude"}
+
+var importDateLayouts = []string{
+	time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02",
+	"02/01/2006 15:04", "02/01/2006", "02-01-2006",
+}
+
+func (m *importMapping) validate(header []string) error {
+	for _, target := range importRequired {
+		if m.Columns[target] == "" {
+			return fmt.Errorf("mapping for %q is required", target)
+		}
+	}
+	present := map[string]bool{}
+	for _, h := range header {
+		present[strings.TrimSpace(h)] = true
+	}
+	for target, column := range m.Columns {
+		if !importTargets[target] {
+			return fmt.Errorf("unknown mapping target %q", target)
+		}
+		if !present[column] {
+			return fmt.Errorf("column %q (mapped to %s) is not in the CSV header", column, target)
+		}
+	}
+	if m.DefaultStatus != "" && !isImportableStatus(m.DefaultStatus) {
+		return fmt.Errorf("default_status %q is not an importable status", m.DefaultStatus)
+	}
+	for legacy, status := range m.StatusMap {
+		if !isImportableStatus(status) {
+			return fmt.Errorf("status_map[%q]: %q is not an importable status", legacy, status)
+		}
+	}
+	return nil
+}
+
+func isImportableStatus(status string) bool {
+	return isKnownStatus(status) && status != StatusMerged
+}
+
+func (m *importMapping) parseTime(value string) (time.Time, error) {
+	if m.DateFormat != "" {
+		return time.ParseInLocation(m.DateFormat, value, time.Local)
+	}
+	for _, layout := range importDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
+
+func importBatchSize() int {
+	return envInt("IMPORT_BATCH_SIZE", 500)
+}
+
+func importStaleAfter() time.Duration {
+	return envDuration("IMPORT_STALE_AFTER", 5*time.Minute)
+}
+
+// ── Row Validation ──────────────────────────────────────────────────────────
+
+// importedRow is a validated CSV row ready to be written.
+type importedRow struct {
+	row        int
+	complaint  Complaint
+	resolvedAt *time.Time
+}
+
+type importRun struct {
+	job           *ImportJob
+	mapping       importMapping
+	index         map[string]int  // target → CSV column index
+	seenRefs      map[string]bool // external_refs of valid rows so far in this file
+	jurisdictions map[[2]float64]jurisdictionLookup
+}
+
+// jurisdictionLookup is a resolveJurisdiction answer cached for the job;
+// exports repeat the same landmark coordinates many times over.
+type jurisdictionLookup struct {
+	match *jurisdictionMatch
+	err   error
+}
+
+func (r *importRun) jurisdiction(lat, lng float64) (*jurisdictionMatch, error) {
+	key := [2]float64{lat, lng}
+	if cached, ok := r.jurisdictions[key]; ok {
+		return cached.match, cached.err
+	}
+	match, err := resolveJurisdiction(db, lat, lng)
+	if err == nil || errors.Is(err, errOutsideJurisdiction) {
+		r.jurisdictions[key] = jurisdictionLookup{match, err}
+	}
+	return match, err
+}
+
+func (r *importRun) field(record []string, target string) string {
+	i, ok := r.index[target]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// parseRow validates one record. All problems are reported, not just the first.
+func (r *importRun) parseRow(row int, record []string) (*importedRow, []ImportRowError) {
+	var errs []ImportRowError
+	fail := func(target, format string, args ...interface{}) {
+		errs = append(errs, ImportRowError{
+			JobID: r.job.ID, RowNumber: row, Column: r.mapping.Columns[target],
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	out := &importedRow{row: row}
+	complaint := &out.complaint
+	complaint.GovernmentID = r.job.GovernmentID
+	complaint.ImportJobID = &r.job.ID
+	complaint.Version = 1
+
+	if complaint.Description = r.field(record, "description"); complaint.Description == "" {
+		fail("description", "description is empty")
+	}
+
+	complaint.Category = r.field(record, "category")
+	if complaint.Category == "" {
+		fail("category", "category is empty")
+	} else if len(r.mapping.Categories) > 0 {
+		matched := ""
+		for _, allowed := range r.mapping.Categories {
+			if strings.EqualFold(allowed, complaint.Category) {
+				matched = allowed
+			}
+		}
+		if matched == "" {
+			fail("category", "category %q is not in the allowed list", complaint.Category)
+		}
+		complaint.Category = matched
+	}
+
+	lat, errLat := strconv.ParseFloat(r.field(record, "latitude"), 64)
+	lng, errLng := strconv.ParseFloat(r.field(record, "longitude"), 64)
+	switch {
+	case errLat != nil || lat < -90 || lat > 90:
+		fail("latitude", "latitude %q is not a number between -90 and 90", r.field(record, "latitude"))
+	case errLng != nil || lng < -180 || lng > 180:
+		fail("longitude", "longitude %q is not a number between -180 and 180", r.field(record, "longitude"))
+	case lat == 0 && lng == 0:
+		fail("latitude", "coordinates are 0,0")
+	default:
+		complaint.Latitude, complaint.Longitude = lat, lng
+		match, err := r.jurisdiction(lat, lng)
+		switch {
+		case errors.Is(err, errOutsideJurisdiction):
+			fail("latitude", "%v", err)
+		case err != nil:
+			fail("latitude", "jurisdiction lookup failed: %v", err)
+		case match != nil && match.GovernmentID != r.job.GovernmentID:
+			fail("latitude", "location belongs to government #%d", match.GovernmentID)
+		case match != nil:
+			complaint.WardID, complaint.Ward = match.WardID, match.Ward
+		}
+	}
+
+	complaint.ManualLocation = r.field(record, "manual_location")
+	complaint.ExternalRef = r.field(record, "external_ref")
+
+	status := strings.ToLower(strings.ReplaceAll(r.field(record, "status"), " ", "_"))
+	if mapped, ok := r.mapping.StatusMap[r.field(record, "status")]; ok {
+		status = mapped
+	}
+	if status == "" {
+		status = r.mapping.DefaultStatus
+	}
+	if status == "" {
+		status = StatusPending
+	}
+	if !isImportableStatus(status) {
+		fail("status", "unknown status %q", r.field(record, "status"))
+	}
+	complaint.Status = status
+
+	now := time.Now()
+	complaint.CreatedAt = now
+	if value := r.field(record, "created_at"); value != "" {
+		t, err := r.mapping.parseTime(value)
+		switch {
+		case err != nil:
+			fail("created_at", "%v", err)
+		case t.After(now):
+			fail("created_at", "created_at %s is in the future", value)
+		default:
+			complaint.CreatedAt = t
+		}
+	}
+	if value := r.field(record, "resolved_at"); value != "" {
+		t, err := r.mapping.parseTime(value)
+		switch {
+		case err != nil:
+			fail("resolved_at", "%v", err)
+		case t.Before(complaint.CreatedAt):
+			fail("resolved_at", "resolved_at %s is before created_at", value)
+		case status != StatusResolved && status != StatusClosed:
+			fail("resolved_at", "resolved_at given for a %s complaint", status)
+		default:
+			out.resolvedAt = &t
+		}
+	}
+
+	if value := r.field(record, "department_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || id == 0 {
+			fail("department_id", "department_id %q is not a positive integer", value)
+		} else {
+			dept := uint(id)
+			complaint.DepartmentID = &dept
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return out, nil
+}
+
+// safeParseRow turns a panic while validating a row into a row error, so
+// one odd record cannot take the job (or the service) down.
+func (r *importRun) safeParseRow(row int, record []string) (parsed *importedRow, errs []ImportRowError) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("[complaint-service] Import job #%d: panic on row %d: %v", r.job.ID, row, p)
+			parsed = nil
+			errs = []ImportRowError{{JobID: r.job.ID, RowNumber: row, Message: "row could not be validated"}}
+		}
+	}()
+	return r.parseRow(row, record)
+}
+
+// failedRowCount counts the distinct rows among rowErrs.
+func failedRowCount(rowErrs []ImportRowError) int {
+	rows := map[int]bool{}
+	for _, e := range rowErrs {
+		rows[e.RowNumber] = true
+	}
+	return len(rows)
+}
+
+// ── Batch Writes ────────────────────────────────────────────────────────────
+
+// commitBatch writes one batch and advances the checkpoint to lastRow.
+func (r *importRun) commitBatch(rows []*importedRow, rowErrs []ImportRowError, lastRow int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// external_ref makes re-imports of the same spreadsheet idempotent;
+		// seenRefs catches a ref repeated within the file, which a dry run
+		// would otherwise never find in the database
+		refs := []string{}
+		for _, row := range rows {
+			if row.complaint.ExternalRef != "" {
+				refs = append(refs, row.complaint.ExternalRef)
+			}
+		}
+		existing := map[string]bool{}
+		if len(refs) > 0 {
+			var found []string
+			if err := tx.Model(&Complaint{}).
+				Where("government_id = ? AND external_ref IN ?", r.job.GovernmentID, refs).
+				Pluck("external_ref", &found).Error; err != nil {
+				return err
+			}
+			for _, ref := range found {
+				existing[ref] = true
+			}
+		}
+		valid := make([]*importedRow, 0, len(rows))
+		for _, row := range rows {
+			ref := row.complaint.ExternalRef
+			if ref != "" && (existing[ref] || r.seenRefs[ref]) {
+				message := fmt.Sprintf("external_ref %q was already imported", ref)
+				if r.seenRefs[ref] {
+					message = fmt.Sprintf("external_ref %q appears earlier in the file", ref)
+				}
+				rowErrs = append(rowErrs, ImportRowError{
+					JobID: r.job.ID, RowNumber: row.row, Column: r.mapping.Columns["external_ref"],
+					Message: message,
+				})
+				continue
+			}
+			if ref != "" {
+				r.seenRefs[ref] = true
+			}
+			valid = append(valid, row)
+		}
+
+		if !r.job.DryRun {
+			for _, row := range valid {
+				if err := r.writeRow(tx, row); err != nil {
+					return err
+				}
+			}
+		}
+		if len(rowErrs) > 0 {
+			if err := tx.CreateInBatches(rowErrs, 200).Error; err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		r.job.ProcessedRows = lastRow
+		r.job.ImportedRows += len(valid)
+		r.job.FailedRows += failedRowCount(rowErrs)
+		r.job.HeartbeatAt = &now
+		return tx.Model(r.job).Updates(map[string]interface{}{
+			"processed_rows": r.job.ProcessedRows,
+			"imported_rows":  r.job.ImportedRows,
+			"failed_rows":    r.job.FailedRows,
+			"heartbeat_at":   now,
+		}).Error
+	})
+}
+
+func (r *importRun) writeRow(tx *gorm.DB, row *importedRow) error {
+	complaint := &row.complaint
+	if err := tx.Create(complaint).Error; err != nil {
+		return err
+	}
+	reason := fmt.Sprintf("imported (job #%d)", r.job.ID)
+	history := []ComplaintStatusHistory{{
+		ComplaintID: complaint.ID, ToStatus: complaint.Status,
+		ActorType: systemActor.Type, Reason: reason, CreatedAt: complaint.CreatedAt,
+	}}
+	if row.resolvedAt != nil {
+		// Keeps resolution-time reporting meaningful for legacy records
+		history[0].ToStatus = StatusPending
+		history = append(history, ComplaintStatusHistory{
+			ComplaintID: complaint.ID, FromStatus: StatusPending, ToStatus: StatusResolved,
+			ActorType: systemActor.Type, Reason: reason, CreatedAt: *row.resolvedAt,
+		})
+		if complaint.Status == StatusClosed {
+			history = append(history, ComplaintStatusHistory{
+				ComplaintID: complaint.ID, FromStatus: StatusResolved, ToStatus: StatusClosed,
+				ActorType: systemActor.Type, Reason: reason, CreatedAt: *row.resolvedAt,
+			})
+		}
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+	return enqueueComplaintEvent(tx, EventComplaintCreated, complaint, map[string]interface{}{
+		"import_job_id": r.job.ID,
+	})
+}
+
+// ── Job Runner ──────────────────────────────────────────────────────────────
+
+// claimImportJob marks a job running if it is pending, failed or stale, so
+// only one worker (across replicas) processes it.
+func claimImportJob(id uint) (bool, error) {
+	now := time.Now()
+	result := db.Model(&ImportJob{}).
+		Where("id = ? AND (status IN ? OR (status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)))",
+			id, []string{ImportPending, ImportFailed}, ImportRunning, now.Add(-importStaleAfter())).
+		Updates(map[string]interface{}{"status": ImportRunning, "heartbeat_at": now, "last_error": ""})
+	return result.RowsAffected == 1, result.Error
+}
+
+func runImportJob(id uint) {
+	var job ImportJob
+	if err := db.First(&job, id).Error; err != nil {
+		log.Printf("[complaint-service] Import job #%d not found: %v", id, err)
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("[complaint-service] Import job #%d panicked at row %d: %v", job.ID, job.ProcessedRows, p)
+			db.Model(&job).Updates(map[string]interface{}{"status": ImportFailed, "last_error": fmt.Sprint("internal error: ", p)})
+		}
+	}()
+	if err := processImport(&job); err != nil {
+		log.Printf("[complaint-service] Import job #%d failed at row %d: %v", job.ID, job.ProcessedRows, err)
+		db.Model(&job).Updates(map[string]interface{}{"status": ImportFailed, "last_error": err.Error()})
+		return
+	}
+	now := time.Now()
+	db.Model(&job).Updates(map[string]interface{}{"status": ImportCompleted, "finished_at": now})
+	log.Printf("[complaint-service] Import job #%d completed: %d imported, %d failed (dry run: %v)",
+		job.ID, job.ImportedRows, job.FailedRows, job.DryRun)
+}
+
+func processImport(job *ImportJob) error {
+	run := &importRun{job: job, index: map[string]int{}, seenRefs: map[string]bool{}, jurisdictions: map[[2]float64]jurisdictionLookup{}}
+	if err := json.Unmarshal(job.Mapping, &run.mapping); err != nil {
+		return fmt.Errorf("invalid mapping: %v", err)
+	}
+
+	obj, err := minioClient.GetObject(context.Background(), env("MINIO_BUCKET", "civic-complaints"), job.ObjectName, minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+	reader := csv.NewReader(obj)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %v", err)
+	}
+	for i, h := range header {
+		h = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		for target, column := range run.mapping.Columns {
+			if column == h {
+				run.index[target] = i
+			}
+		}
+	}
+
+	// Skip rows committed by a previous attempt, remembering the refs of
+	// the valid ones
+	var failed []int
+	if job.ProcessedRows > 0 {
+		if err := db.Model(&ImportRowError{}).Where("job_id = ?", job.ID).Distinct().Pluck("row_number", &failed).Error; err != nil {
+			return err
+		}
+	}
+	failedRows := map[int]bool{}
+	for _, n := range failed {
+		failedRows[n] = true
+	}
+	row := 0
+	for row < job.ProcessedRows {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return err
+			}
+		}
+		row++
+		if ref := run.field(record, "external_ref"); ref != "" && err == nil && !failedRows[row] {
+			run.seenRefs[ref] = true
+		}
+	}
+
+	batchSize := importBatchSize()
+	var (
+		batch   []*importedRow
+		rowErrs []ImportRowError
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		row++
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			rowErrs = append(rowErrs, ImportRowError{JobID: job.ID, RowNumber: row, Message: parseErr.Error()})
+		case err != nil:
+			return err
+		default:
+			parsed, errs := run.safeParseRow(row, record)
+			if parsed != nil {
+				batch = append(batch, parsed)
+			}
+			rowErrs = append(rowErrs, errs...)
+		}
+		if row-job.ProcessedRows >= batchSize {
+			if err := run.commitBatch(batch, rowErrs, row); err != nil {
+				return err
+			}
+			batch, rowErrs = nil, nil
+		}
+	}
+	if row > job.ProcessedRows {
+		return run.commitBatch(batch, rowErrs, row)
+	}
+	return nil
+}
+
+// runImportResumer starts pending jobs and restarts those whose worker died
+// (no heartbeat for IMPORT_STALE_AFTER), e.g. after a redeploy.
+func runImportResumer() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		var ids []uint
+		db.Model(&ImportJob{}).
+			Where("status = ? OR (status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?))",
+				ImportPending, ImportRunning, time.Now().Add(-importStaleAfter())).
+			Pluck("id", &ids)
+		for _, id := range ids {
+			if ok, err := claimImportJob(id); err == nil && ok {
+				log.Printf("[complaint-service] Resuming import job #%d", id)
+				go runImportJob(id)
+			}
+		}
+	}
+}
+
+// ── Import Handlers ─────────────────────────────────────────────────────────
+
+// POST /complaints/imports (multipart: file, mapping, dry_run, government_id)
+func createImportHandler(c *gin.Context) {
+	maxBytes, _ := strconv.ParseInt(env("IMPORT_MAX_BYTES", "52428800"), 10, 64)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no CSV file provided"})
+		return
+	}
+	defer file.Close()
+
+	govID := getGovID(c)
+	if getAdminRole(c) == "super_admin" {
+		if id, err := strconv.ParseUint(c.PostForm("government_id"), 10, 64); err == nil {
+			govID = uint(id)
+		}
+	}
+	if govID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "government_id is required"})
+		return
+	}
+
+	var mapping importMapping
+	if err := json.Unmarshal([]byte(c.PostForm("mapping")), &mapping); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be JSON: " + err.Error()})
+		return
+	}
+	csvHeader, err := csv.NewReader(file).Read()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot read CSV header: " + err.Error()})
+		return
+	}
+	for i, h := range csvHeader {
+		csvHeader[i] = strings.TrimPrefix(h, "\ufeff")
+	}
+	if err := mapping.validate(csvHeader); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	objectName := fmt.Sprintf("imports/%d/%d.csv", govID, time.Now().UnixNano())
+	_, err = minioClient.PutObject(context.Background(), env("MINIO_BUCKET", "civic-complaints"),
+		objectName, file, header.Size, minio.PutObjectOptions{ContentType: "text/csv"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	mappingJSON, _ := json.Marshal(mapping)
+	job := ImportJob{
+		GovernmentID: govID,
+		AdminID:      getAdminID(c),
+		Filename:     header.Filename,
+		ObjectName:   objectName,
+		Mapping:      mappingJSON,
+		DryRun:       c.PostForm("dry_run") == "true",
+		Status:       ImportPending,
+	}
+	if err := db.Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if ok, _ := claimImportJob(job.ID); ok {
+		job.Status = ImportRunning
+		go runImportJob(job.ID)
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// loadImportJob fetches a job the caller may see, or writes the error response.
+func loadImportJob(c *gin.Context) (*ImportJob, bool) {
+	var job ImportJob
+	if err := db.First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import job not found"})
+		return nil, false
+	}
+	if !canManageGovernment(c, job.GovernmentID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "import job belongs to another government"})
+		return nil, false
+	}
+	return &job, true
+}
+
+func listImportsHandler(c *gin.Context) {
+	query := db.Order("created_at DESC").Limit(pageLimit(c))
+	if getAdminRole(c) != "super_admin" {
+		query = query.Where("government_id = ?", getGovID(c))
+	} else if govID := c.Query("government_id"); govID != "" {
+		query = query.Where("government_id = ?", govID)
+	}
+	var jobs []ImportJob
+	query.Find(&jobs)
+	c.JSON(http.StatusOK, jobs)
+}
+
+func getImportHandler(c *gin.Context) {
+	if job, ok := loadImportJob(c); ok {
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// GET /complaints/imports/:id/errors?limit=&offset=
+func importErrorsHandler(c *gin.Context) {
+	job, ok := loadImportJob(c)
+	if !ok {
+		return
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+	limit := pageLimit(c)
+	var total int64
+	db.Model(&ImportRowError{}).Where("job_id = ?", job.ID).Count(&total)
+	var rowErrs []ImportRowError
+	db.Where("job_id = ?", job.ID).Order("row_number, id").Limit(limit).Offset(offset).Find(&rowErrs)
+	c.JSON(http.StatusOK, gin.H{"errors": rowErrs, "total": total, "limit": limit, "offset": offset})
+}
+
+// POST /complaints/imports/:id/resume — continue a failed job from its checkpoint
+func resumeImportHandler(c *gin.Context) {
+	job, ok := loadImportJob(c)
+	if !ok {
+		return
+	}
+	claimed, err := claimImportJob(job.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusConflict, gin.H{"error": "import job is " + job.Status + " and cannot be resumed"})
+		return
+	}
+	go runImportJob(job.ID)
+	job.Status = ImportRunning
+	c.JSON(http.StatusAccepted, job)
+}
+
+// POST /complaints/imports/:id/commit — run a finished dry run for real
+func commitImportHandler(c *gin.Context) {
+	dryRun, ok := loadImportJob(c)
+	if !ok {
+		return
+	}
+	if !dryRun.DryRun || dryRun.Status != ImportCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "only a completed dry run can be committed"})
+		return
+	}
+	job := ImportJob{
+		GovernmentID: dryRun.GovernmentID,
+		AdminID:      getAdminID(c),
+		Filename:     dryRun.Filename,
+		ObjectName:   dryRun.ObjectName,
+		Mapping:      dryRun.Mapping,
+		Status:       ImportPending,
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		// Guarded so two concurrent commits cannot both start a job
+		res := tx.Model(&ImportJob{}).Where("id = ? AND committed_as IS NULL", dryRun.ID).Update("committed_as", job.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errDryRunCommitted
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRunCommitted) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if ok, _ := claimImportJob(job.ID); ok {
+		job.Status = ImportRunning
+		go runImportJob(job.ID)
+	}
+	c.JSON(http.StatusAccepted, job)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// testImportRun maps the CSV header description,category,lat,lng,status,
+// opened,closed,dept,ref onto the import targets.
+func testImportRun(mapping importMapping) *importRun {
+	mapping.Columns = map[string]string{
+		"description": "description", "category": "category", "latitude": "lat", "longitude": "lng",
+		"status": "status", "created_at": "opened", "resolved_at": "closed",
+		"department_id": "dept", "external_ref": "ref",
+	}
+	header := []string{"description", "category", "lat", "lng", "status", "opened", "closed", "dept", "ref"}
+	run := &importRun{
+		job: &ImportJob{ID: 9, GovernmentID: 3}, mapping: mapping,
+		index: map[string]int{}, seenRefs: map[string]bool{}, jurisdictions: map[[2]float64]jurisdictionLookup{},
+	}
+	for i, h := range header {
+		for target, column := range mapping.Columns {
+			if column == h {
+				run.index[target] = i
+			}
+		}
+	}
+	return run
+}
+
+func TestParseRow(t *testing.T) {
+	opened := time.Date(2024, 5, 2, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name    string
+		mapping importMapping
+		record  []string
+		check   func(t *testing.T, row *importedRow)
+		errCols []string // columns of the expected row errors, in order
+	}{
+		{
+			name:   "minimal row",
+			record: []string{"Pothole on MG Road", "Roads", "12.97", "77.59", "", "", "", "", ""},
+			check: func(t *testing.T, row *importedRow) {
+				c := row.complaint
+				if c.GovernmentID != 3 || *c.ImportJobID != 9 || c.Status != StatusPending || c.Version != 1 {
+					t.Errorf("complaint = gov %d job %d %s v%d", c.GovernmentID, *c.ImportJobID, c.Status, c.Version)
+				}
+				if c.Latitude != 12.97 || c.Longitude != 77.59 {
+					t.Errorf("location = %v,%v", c.Latitude, c.Longitude)
+				}
+			},
+		},
+		{
+			name:   "full resolved row",
+			record: []string{" Streetlight out ", "Electricity", "12.97", "77.59", "Resolved", "2024-05-02", "02/06/2024", "14", "LEG-1"},
+			check: func(t *testing.T, row *importedRow) {
+				c := row.complaint
+				if c.Description != "Streetlight out" || c.Status != StatusResolved || c.ExternalRef != "LEG-1" {
+					t.Errorf("complaint = %q %s %q", c.Description, c.Status, c.ExternalRef)
+				}
+				if !c.CreatedAt.Equal(opened) {
+					t.Errorf("created_at = %v, want %v", c.CreatedAt, opened)
+				}
+				if row.resolvedAt == nil || !row.resolvedAt.Equal(time.Date(2024, 6, 2, 0, 0, 0, 0, time.Local)) {
+					t.Errorf("resolved_at = %v", row.resolvedAt)
+				}
+				if c.DepartmentID == nil || *c.DepartmentID != 14 {
+					t.Errorf("department_id = %v", c.DepartmentID)
+				}
+			},
+		},
+		{
+			name:    "status map and allowed categories",
+			mapping: importMapping{StatusMap: map[string]string{"Open": StatusInProgress}, Categories: []string{"Water Supply"}},
+			record:  []string{"No water", "water supply", "12.97", "77.59", "Open", "", "", "", ""},
+			check: func(t *testing.T, row *importedRow) {
+				if row.complaint.Status != StatusInProgress || row.complaint.Category != "Water Supply" {
+					t.Errorf("complaint = %s %q", row.complaint.Status, row.complaint.Category)
+				}
+			},
+		},
+		{
+			name:    "default status",
+			mapping: importMapping{DefaultStatus: StatusAcknowledged},
+			record:  []string{"No water", "Water", "12.97", "77.59", "", "", "", "", ""},
+			check: func(t *testing.T, row *importedRow) {
+				if row.complaint.Status != StatusAcknowledged {
+					t.Errorf("status = %s", row.complaint.Status)
+				}
+			},
+		},
+		{
+			name:    "every problem is reported",
+			record:  []string{"", "", "12.97", "77.59", "lost", "yesterday", "", "-4", ""},
+			errCols: []string{"description", "category", "status", "opened", "dept"},
+		},
+		{
+			name:    "category outside the allowed list",
+			mapping: importMapping{Categories: []string{"Roads"}},
+			record:  []string{"Noise", "Noise", "12.97", "77.59", "", "", "", "", ""},
+			errCols: []string{"category"},
+		},
+		{name: "latitude out of range", record: []string{"x", "y", "97", "77.59", "", "", "", "", ""}, errCols: []string{"lat"}},
+		{name: "longitude not a number", record: []string{"x", "y", "12.97", "east", "", "", "", "", ""}, errCols: []string{"lng"}},
+		{name: "null island", record: []string{"x", "y", "0", "0", "", "", "", "", ""}, errCols: []string{"lat"}},
+		{name: "merged is not importable", record: []string{"x", "y", "12.97", "77.59", "merged", "", "", "", ""}, errCols: []string{"status"}},
+		{name: "created in the future", record: []string{"x", "y", "12.97", "77.59", "", "2999-01-01", "", "", ""}, errCols: []string{"opened"}},
+		{
+			name:    "resolved before created",
+			record:  []string{"x", "y", "12.97", "77.59", "resolved", "2024-05-02", "2024-05-01", "", ""},
+			errCols: []string{"closed"},
+		},
+		{
+			name:    "resolved_at on an open complaint",
+			record:  []string{"x", "y", "12.97", "77.59", "in progress", "2024-05-02", "2024-05-03", "", ""},
+			errCols: []string{"closed"},
+		},
+		{name: "short record", record: []string{"x", "y"}, errCols: []string{"lat"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newStubDB(t) // no boundaries configured
+			row, errs := testImportRun(tt.mapping).parseRow(4, tt.record)
+			if len(tt.errCols) == 0 {
+				if len(errs) > 0 {
+					t.Fatalf("unexpected row errors: %+v", errs)
+				}
+				tt.check(t, row)
+				return
+			}
+			if row != nil {
+				t.Errorf("invalid row returned %+v", row)
+			}
+			var cols []string
+			for _, e := range errs {
+				if e.JobID != 9 || e.RowNumber != 4 {
+					t.Errorf("row error %+v not tied to job 9 row 4", e)
+				}
+				cols = append(cols, e.Column)
+			}
+			if strings.Join(cols, ",") != strings.Join(tt.errCols, ",") {
+				t.Errorf("error columns = %v, want %v (%+v)", cols, tt.errCols, errs)
+			}
+		})
+	}
+}
+
+func TestParseRowCachesJurisdiction(t *testing.T) {
+	stub := newStubDB(t)
+	run := testImportRun(importMapping{})
+	for i, coords := range [][2]string{{"12.97", "77.59"}, {"12.97", "77.59"}, {"12.98", "77.59"}, {"12.97", "77.59"}} {
+		if _, errs := run.parseRow(i+2, []string{"Pothole", "Roads", coords[0], coords[1], "", "", "", "", ""}); len(errs) != 0 {
+			t.Fatalf("row %d: %v", i+2, errs)
+		}
+	}
+	lookups := 0
+	for _, q := range stub.executed {
+		if strings.Contains(q, "jurisdiction_boundaries") {
+			lookups++
+		}
+	}
+	if lookups != 2 {
+		t.Errorf("jurisdiction lookups = %d, want one per distinct location (2)", lookups)
+	}
+}
+
+func TestFailedRowCount(t *testing.T) {
+	errs := []ImportRowError{{RowNumber: 1}, {RowNumber: 1}, {RowNumber: 3}, {RowNumber: 7}, {RowNumber: 3}}
+	if got := failedRowCount(errs); got != 3 {
+		t.Errorf("failedRowCount() = %d, want 3", got)
+	}
+	if got := failedRowCount(nil); got != 0 {
+		t.Errorf("failedRowCount(nil) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ── Jurisdiction Boundaries ─────────────────────────────────────────────────
+//
+// Super admins upload municipality and ward polygons per LocalGovernment as
+// GeoJSON (a FeatureCollection, a single Feature or a bare geometry). On
+// complaint creation the government and ward are resolved from the point;
+// the client-supplied government_id is ignored once any boundary exists.
+
+const (
+	BoundaryMunicipality = "municipality"
+	BoundaryWard         = "ward"
+)
+
+var errOutsideJurisdiction = errors.New("location is outside every known jurisdiction")
+
+type JurisdictionBoundary struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	GovernmentID uint      `gorm:"index;not null" json:"government_id"`
+	Kind         string    `gorm:"not null;index" json:"kind"` // municipality | ward
+	Name         string    `json:"name"`
+	Code         string    `json:"code,omitempty"`
+	Geom         string    `gorm:"type:geometry(MultiPolygon,4326);not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// jurisdictionMatch is the result of a point-in-polygon lookup.
+type jurisdictionMatch struct {
+	GovernmentID uint   `json:"government_id"`
+	WardID       *uint  `json:"ward_id,omitempty"`
+	Ward         string `json:"ward,omitempty"`
+}
+
+// resolveJurisdiction finds the government (and ward) covering a point. The
+// smallest matching municipality wins; a ward alone is enough to place the
+// point. With no boundaries configured at all it returns nil so deployments
+// keep working until boundaries are uploaded.
+func resolveJurisdiction(tx *gorm.DB, lat, lng float64) (*jurisdictionMatch, error) {
+	var configured bool
+	if err := tx.Raw("SELECT EXISTS (SELECT 1 FROM jurisdiction_boundaries)").Scan(&configured).Error; err != nil {
+		return nil, err
+	}
+	if !configured {
+		return nil, nil
+	}
+
+	point := "geom && ST_SetSRID(ST_MakePoint(?, ?), 4326) AND ST_Covers(geom, ST_SetSRID(ST_MakePoint(?, ?), 4326))"
+	var municipality JurisdictionBoundary
+	err := tx.Select("id, government_id, kind, name, code").
+		Where("kind = ?", BoundaryMunicipality).
+		Where(point, lng, lat, lng, lat).
+		Order("ST_Area(geom)").Limit(1).Find(&municipality).Error
+	if err != nil {
+		return nil, err
+	}
+
+	wards := tx.Select("id, government_id, kind, name, code").
+		Where("kind = ?", BoundaryWard).
+		Where(point, lng, lat, lng, lat)
+	if municipality.ID != 0 {
+		wards = wards.Where("government_id = ?", municipality.GovernmentID)
+	}
+	var ward JurisdictionBoundary
+	if err := wards.Order("ST_Area(geom)").Limit(1).Find(&ward).Error; err != nil {
+		return nil, err
+	}
+
+	switch {
+	case municipality.ID != 0:
+		match := &jurisdictionMatch{GovernmentID: municipality.GovernmentID}
+		if ward.ID != 0 {
+			match.WardID, match.Ward = &ward.ID, ward.Name
+		}
+		return match, nil
+	case ward.ID != 0:
+		return &jurisdictionMatch{GovernmentID: ward.GovernmentID, WardID: &ward.ID, Ward: ward.Name}, nil
+	}
+	return nil, errOutsideJurisdiction
+}
+
+// ── GeoJSON Input ───────────────────────────────────────────────────────────
+
+type geoJSONObject struct {
+	Type       string                 `json:"type"`
+	Features   []geoJSONObject        `json:"features,omitempty"`
+	Geometry   json.RawMessage        `json:"geometry,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type boundaryInput struct {
+	Name     string
+	Code     string
+	Kind     string
+	Geometry json.RawMessage
+}
+
+// flattenBoundaries turns a FeatureCollection / Feature / geometry into one
+// input per polygon feature. Feature properties name, code and kind override
+// the request defaults.
+func flattenBoundaries(raw json.RawMessage, kind, name string) ([]boundaryInput, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+	var features []geoJSONObject
+	switch obj.Type {
+	case "FeatureCollection":
+		features = obj.Features
+	case "Feature":
+		features = []geoJSONObject{obj}
+	case "Polygon", "MultiPolygon":
+		features = []geoJSONObject{{Type: "Feature", Geometry: raw}}
+	default:
+		return nil, fmt.Errorf("GeoJSON must be a FeatureCollection, Feature, Polygon or MultiPolygon")
+	}
+
+	inputs := make([]boundaryInput, 0, len(features))
+	for i, f := range features {
+		var geom struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(f.Geometry, &geom); err != nil || (geom.Type != "Polygon" && geom.Type != "MultiPolygon") {
+			return nil, fmt.Errorf("feature %d: geometry must be a Polygon or MultiPolygon", i)
+		}
+		prop := func(key string) string {
+			if v, ok := f.Properties[key]; ok && v != nil {
+				return fmt.Sprint(v)
+			}
+			return ""
+		}
+		in := boundaryInput{Name: name, Kind: kind, Geometry: f.Geometry}
+		if v := prop("name"); v != "" {
+			in.Name = v
+		}
+		if v := prop("code"); v != "" {
+			in.Code = v
+		}
+		if v := prop("kind"); v != "" {
+			in.Kind = v
+		}
+		if in.Kind != BoundaryMunicipality && in.Kind != BoundaryWard {
+			return nil, fmt.Errorf("feature %d: kind must be municipality or ward", i)
+		}
+		inputs = append(inputs, in)
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("GeoJSON contains no features")
+	}
+	return inputs, nil
+}
+
+// ── Jurisdiction Handlers ───────────────────────────────────────────────────
+
+// POST /complaints/jurisdictions (super_admin)
+// Body: {government_id, kind, name, replace, geojson}. replace=true deletes the
+// government's existing boundaries of the uploaded kinds first.
+func uploadBoundariesHandler(c *gin.Context) {
+	var body struct {
+		GovernmentID uint            `json:"government_id" binding:"required"`
+		Kind         string          `json:"kind"`
+		Name         string          `json:"name"`
+		Replace      bool            `json:"replace"`
+		GeoJSON      json.RawMessage `json:"geojson" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Kind == "" {
+		body.Kind = BoundaryMunicipality
+	}
+	inputs, err := flattenBoundaries(body.GeoJSON, body.Kind, body.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var created []JurisdictionBoundary
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if body.Replace {
+			kinds := map[string]bool{}
+			for _, in := range inputs {
+				kinds[in.Kind] = true
+			}
+			for kind := range kinds {
+				if err := tx.Where("government_id = ? AND kind = ?", body.GovernmentID, kind).
+					Delete(&JurisdictionBoundary{}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for i, in := range inputs {
+			var valid bool
+			if err := tx.Raw("SELECT ST_IsValid(ST_GeomFromGeoJSON(?))", string(in.Geometry)).Row().Scan(&valid); err != nil {
+				return fmt.Errorf("feature %d: %v", i, err)
+			}
+			if !valid {
+				return fmt.Errorf("feature %d: polygon is not valid (self-intersecting or unclosed)", i)
+			}
+			boundary := JurisdictionBoundary{GovernmentID: body.GovernmentID, Kind: in.Kind, Name: in.Name, Code: in.Code}
+			err := tx.Raw(`
+				INSERT INTO jurisdiction_boundaries (government_id, kind, name, code, geom, created_at)
+				VALUES (?, ?, ?, ?, ST_Multi(ST_SetSRID(ST_GeomFromGeoJSON(?), 4326)), NOW())
+				RETURNING id, created_at`,
+				boundary.GovernmentID, boundary.Kind, boundary.Name, boundary.Code, string(in.Geometry)).
+				Row().Scan(&boundary.ID, &boundary.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("feature %d: %v", i, err)
+			}
+			created = append(created, boundary)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"boundaries": created})
+}
+
+// GET /complaints/jurisdictions?government_id=&kind= — FeatureCollection
+func listBoundariesHandler(c *gin.Context) {
+	query := db.Model(&JurisdictionBoundary{}).
+		Select("id, government_id, kind, name, code, created_at, geom")
+	if govID := c.Query("government_id"); govID != "" {
+		query = query.Where("government_id = ?", govID)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var collection string
+	err := db.Raw(`
+		SELECT json_build_object(
+			'type', 'FeatureCollection',
+			'features', COALESCE(json_agg(ST_AsGeoJSON(t.*, 'geom', 6)::json ORDER BY t.id), '[]'::json)
+		)::text
+		FROM (?) AS t`, query).Row().Scan(&collection)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/geo+json", []byte(collection))
+}
+
+// DELETE /complaints/jurisdictions/:id (super_admin)
+func deleteBoundaryHandler(c *gin.Context) {
+	result := db.Delete(&JurisdictionBoundary{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "boundary not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "boundary deleted"})
+}
+
+// GET /complaints/jurisdictions/resolve?lat=&lng= — lets apps preview where a
+// complaint will be filed before submitting it.
+func resolveJurisdictionHandler(c *gin.Context) {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng are required"})
+		return
+	}
+	match, err := resolveJurisdiction(db, lat, lng)
+	if errors.Is(err, errOutsideJurisdiction) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if match == nil {
+		c.JSON(http.StatusOK, gin.H{"configured": false})
+		return
+	}
+	c.JSON(http.StatusOK, match)
+}
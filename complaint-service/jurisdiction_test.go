@@ -0,0 +1,103 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testPolygon = `{"type":"Polygon","coordinates":[[[77.5,12.9],[77.7,12.9],[77.7,13.1],[77.5,12.9]]]}`
+
+func TestFlattenBoundaries(t *testing.T) {
+	feature := func(props string) string {
+		return `{"type":"Feature","properties":` + props + `,"geometry":` + testPolygon + `}`
+	}
+	tests := []struct {
+		name    string
+		geojson string
+		want    []boundaryInput // Geometry is not compared
+		wantErr string
+	}{
+		{
+			name:    "bare polygon takes the defaults",
+			geojson: testPolygon,
+			want:    []boundaryInput{{Name: "Bengaluru", Kind: BoundaryMunicipality}},
+		},
+		{
+			name:    "bare multipolygon",
+			geojson: `{"type":"MultiPolygon","coordinates":[]}`,
+			want:    []boundaryInput{{Name: "Bengaluru", Kind: BoundaryMunicipality}},
+		},
+		{
+			name:    "feature properties override",
+			geojson: feature(`{"name":"Ward 12","code":12,"kind":"ward"}`),
+			want:    []boundaryInput{{Name: "Ward 12", Code: "12", Kind: BoundaryWard}},
+		},
+		{
+			name:    "null properties keep the defaults",
+			geojson: feature(`{"name":null,"code":null}`),
+			want:    []boundaryInput{{Name: "Bengaluru", Kind: BoundaryMunicipality}},
+		},
+		{
+			name: "feature collection",
+			geojson: `{"type":"FeatureCollection","features":[` +
+				feature(`{"name":"Ward 1","kind":"ward"}`) + `,` + feature(`{"name":"Ward 2","kind":"ward"}`) + `]}`,
+			want: []boundaryInput{{Name: "Ward 1", Kind: BoundaryWard}, {Name: "Ward 2", Kind: BoundaryWard}},
+		},
+		{name: "empty collection", geojson: `{"type":"FeatureCollection","features":[]}`, wantErr: "no features"},
+		{name: "not json", geojson: `{"type":`, wantErr: "invalid GeoJSON"},
+		{name: "point geometry", geojson: `{"type":"Point","coordinates":[77.6,12.97]}`, wantErr: "must be a FeatureCollection"},
+		{
+			name:    "feature with point",
+			geojson: `{"type":"Feature","properties":{},"geometry":{"type":"Point","coordinates":[77.6,12.97]}}`,
+			wantErr: "feature 0: geometry",
+		},
+		{name: "feature without geometry", geojson: `{"type":"Feature","properties":{}}`, wantErr: "feature 0: geometry"},
+		{name: "unknown kind", geojson: feature(`{"kind":"district"}`), wantErr: "feature 0: kind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := flattenBoundaries(json.RawMessage(tt.geojson), BoundaryMunicipality, "Bengaluru")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d inputs, want %d", len(got), len(tt.want))
+			}
+			for i, in := range got {
+				if in.Name != tt.want[i].Name || in.Code != tt.want[i].Code || in.Kind != tt.want[i].Kind {
+					t.Errorf("input %d = %+v, want %+v", i, in, tt.want[i])
+				}
+				if len(in.Geometry) == 0 {
+					t.Errorf("input %d has no geometry", i)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveJurisdiction(t *testing.T) {
+	t.Run("no boundaries configured", func(t *testing.T) {
+		newStubDB(t)
+		match, err := resolveJurisdiction(db, 12.97, 77.59)
+		if match != nil || err != nil {
+			t.Fatalf("got %+v, %v; want nil, nil", match, err)
+		}
+	})
+	t.Run("outside every boundary", func(t *testing.T) {
+		stub := newStubDB(t)
+		stub.returns("SELECT EXISTS", []string{"exists"}, []driver.Value{true})
+		match, err := resolveJurisdiction(db, 12.97, 77.59)
+		if match != nil || !errors.Is(err, errOutsideJurisdiction) {
+			t.Fatalf("got %+v, %v; want %v", match, err, errOutsideJurisdiction)
+		}
+	})
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ── Status Lifecycle ────────────────────────────────────────────────────────
+//
+//   pending → acknowledged → in_progress → resolved → closed
+//                                              ↘ reopened → in_progress …
+//   in_progress → pending_verification → resolved | in_progress when the
+//   government requires manager sign-off (see approval.go).
+//   rejected is reachable from any open state and requires a reason.
+//   merged is reachable from any open state (see merge.go).
+//   resolved → closed | reopened is the citizen's call (see confirmation.go);
+//   staff cannot close a resolved complaint themselves.
+
+const (
+	StatusPending             = "pending"
+	StatusAcknowledged        = "acknowledged"
+	StatusInProgress          = "in_progress"
+	StatusPendingVerification = "pending_verification"
+	StatusResolved            = "resolved"
+	StatusClosed              = "closed"
+	StatusReopened            = "reopened"
+	StatusRejected            = "rejected"
+	StatusMerged              = "merged"
+)
+
+var statusTransitions = map[string][]string{
+	StatusPending:             {StatusAcknowledged, StatusInProgress, StatusRejected, StatusMerged},
+	StatusAcknowledged:        {StatusInProgress, StatusRejected, StatusMerged},
+	StatusInProgress:          {StatusResolved, StatusPendingVerification, StatusRejected, StatusMerged},
+	StatusPendingVerification: {StatusResolved, StatusInProgress},
+	StatusResolved:            {StatusClosed, StatusReopened},
+	StatusReopened:            {StatusAcknowledged, StatusInProgress, StatusRejected, StatusMerged},
+	StatusClosed:              {},
+	StatusRejected:            {},
+	StatusMerged:              {},
+}
+
+var (
+	errUnknownStatus     = errors.New("unknown status")
+	errIllegalTransition = errors.New("illegal status transition")
+	errReasonRequired    = errors.New("a reason is required for this transition")
+	errConcurrentChange  = errors.New("complaint status changed concurrently, reload and retry")
+)
+
+// ComplaintStatusHistory — one row per status transition (audit trail)
+type ComplaintStatusHistory struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ComplaintID uint      `gorm:"index;not null" json:"complaint_id"`
+	FromStatus  string    `json:"from_status"`
+	ToStatus    string    `gorm:"not null" json:"to_status"`
+	ActorID     uint      `json:"actor_id"`
+	ActorType   string    `gorm:"not null;default:system" json:"actor_type"` // citizen | admin | system
+	Reason      string    `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// statusActor identifies who triggered a transition.
+type statusActor struct {
+	ID   uint
+	Type string
+}
+
+var systemActor = statusActor{Type: "system"}
+
+func isKnownStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+func canTransition(from, to string) bool {
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+func isTerminalStatus(status string) bool {
+	return isKnownStatus(status) && len(statusTransitions[status]) == 0
+}
+
+// transitionStatus moves the complaint to a new status inside tx and records
+// the history row. The update is guarded on the current status so two
+// concurrent transitions cannot both succeed.
+func transitionStatus(tx *gorm.DB, complaint *Complaint, to string, actor statusActor, reason string) error {
+	if !isKnownStatus(to) {
+		return fmt.Errorf("%w: %q", errUnknownStatus, to)
+	}
+	from := complaint.Status
+	if !canTransition(from, to) {
+		return fmt.Errorf("%w: %s → %s", errIllegalTransition, from, to)
+	}
+	if to == StatusRejected && reason == "" {
+		return errReasonRequired
+	}
+	if from == StatusResolved && to == StatusClosed && actor.Type == "admin" {
+		return errCitizenConfirms
+	}
+	if to == StatusResolved && from != StatusPendingVerification && requiresApproval(tx, complaint) {
+		return errApprovalRequired
+	}
+
+	changes := map[string]interface{}{"status": to, "version": gorm.Expr("version + 1")}
+	switch {
+	case to == StatusResolved:
+		due := time.Now().Add(confirmationWindow())
+		changes["confirmation_due_at"], complaint.ConfirmationDueAt = due, &due
+	case from == StatusResolved:
+		changes["confirmation_due_at"], complaint.ConfirmationDueAt = nil, nil
+	}
+	if to == StatusReopened {
+		changes["reopen_count"] = gorm.Expr("reopen_count + 1")
+		complaint.ReopenCount++
+		// The old resolve deadline has usually passed; the work starts over
+		stampSLA(tx, complaint, time.Now())
+		for column, value := range slaColumns(complaint) {
+			changes[column] = value
+		}
+	}
+	res := tx.Model(&Complaint{}).
+		Where("id = ? AND status = ?", complaint.ID, from).
+		Updates(changes)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errConcurrentChange
+	}
+
+	history := ComplaintStatusHistory{
+		ComplaintID: complaint.ID,
+		FromStatus:  from,
+		ToStatus:    to,
+		ActorID:     actor.ID,
+		ActorType:   actor.Type,
+		Reason:      reason,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+
+	complaint.Status = to
+	complaint.Version++
+	return enqueueComplaintEvent(tx, EventComplaintStatusChanged, complaint, map[string]interface{}{
+		"from_status": from,
+		"to_status":   to,
+		"reason":      reason,
+		"actor_id":    actor.ID,
+		"actor_type":  actor.Type,
+	})
+}
+
+// updateComplaintVersioned writes changes and bumps the version, guarded on
+// the version the caller read, so a concurrent status change, edit or
+// reassignment is reported instead of overwritten.
+func updateComplaintVersioned(tx *gorm.DB, complaint *Complaint, changes map[string]interface{}) error {
+	changes["version"] = complaint.Version + 1
+	res := tx.Model(&Complaint{}).
+		Where("id = ? AND version = ?", complaint.ID, complaint.Version).
+		Updates(changes)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errConcurrentChange
+	}
+	complaint.Version++
+	return nil
+}
+
+// recordInitialStatus writes the "" → pending history row for a new complaint.
+func recordInitialStatus(tx *gorm.DB, complaint *Complaint, actor statusActor) error {
+	return tx.Create(&ComplaintStatusHistory{
+		ComplaintID: complaint.ID,
+		ToStatus:    complaint.Status,
+		ActorID:     actor.ID,
+		ActorType:   actor.Type,
+	}).Error
+}
+
+// advanceForCompletion applies the status side effects of an ActionTaken:
+// any progress moves the complaint to in_progress, 100% resolves it or,
+// when approval is required, sends it for verification. Steps that are not
+// legal from the current status are skipped, and a complaint awaiting
+// verification stays there until a manager decides.
+func advanceForCompletion(tx *gorm.DB, complaint *Complaint, percent int, actor statusActor) error {
+	if complaint.Status == StatusPendingVerification {
+		return nil
+	}
+	var targets []string
+	if percent > 0 {
+		targets = append(targets, StatusInProgress)
+	}
+	if percent >= 100 {
+		if requiresApproval(tx, complaint) {
+			targets = append(targets, StatusPendingVerification)
+		} else {
+			targets = append(targets, StatusResolved)
+		}
+	}
+	for _, to := range targets {
+		if complaint.Status == to || !canTransition(complaint.Status, to) {
+			continue
+		}
+		if err := transitionStatus(tx, complaint, to, actor, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statusErrorCode maps lifecycle errors to HTTP status codes.
+func statusErrorCode(err error) int {
+	switch {
+	case errors.Is(err, errUnknownStatus), errors.Is(err, errReasonRequired):
+		return http.StatusBadRequest
+	case errors.Is(err, errIllegalTransition), errors.Is(err, errConcurrentChange), errors.Is(err, errCitizenConfirms),
+		errors.Is(err, errApprovalRequired), errors.Is(err, errAwaitingVerification):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// ── Status Handlers ─────────────────────────────────────────────────────────
+
+func changeStatusHandler(c *gin.Context) {
+	id := c.Param("id")
+	var complaint Complaint
+	if err := db.First(&complaint, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "complaint not found"})
+		return
+	}
+
+	if !canManageComplaint(c, &complaint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "complaint is outside your jurisdiction"})
+		return
+	}
+
+	var body struct {
+		Status string `json:"status" binding:"required"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Verification is entered by the final action and left by a manager's
+	// decision, never by a plain status change
+	if complaint.Status == StatusPendingVerification || body.Status == StatusPendingVerification {
+		c.JSON(http.StatusConflict, gin.H{"error": errAwaitingVerification.Error()})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return transitionStatus(tx, &complaint, body.Status, actorFromContext(c), body.Reason)
+	})
+	if err != nil {
+		c.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, complaint)
+}
+
+func statusHistoryHandler(c *gin.Context) {
+	id := c.Param("id")
+	var complaint Complaint
+	if err := db.Select("id").First(&complaint, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "complaint not found"})
+		return
+	}
+	var history []ComplaintStatusHistory
+	db.Where("complaint_id = ?", complaint.ID).Order("created_at ASC, id ASC").Find(&history)
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{StatusPending, StatusAcknowledged, true},
+		{StatusPending, StatusInProgress, true},
+		{StatusPending, StatusResolved, false},
+		{StatusAcknowledged, StatusInProgress, true},
+		{StatusAcknowledged, StatusPending, false},
+		{StatusInProgress, StatusResolved, true},
+		{StatusInProgress, StatusRejected, true},
+		{StatusResolved, StatusClosed, true},
+		{StatusResolved, StatusReopened, true},
+		{StatusResolved, StatusInProgress, false},
+		{StatusReopened, StatusInProgress, true},
+		{StatusClosed, StatusReopened, false},
+		{StatusRejected, StatusPending, false},
+		{StatusMerged, StatusPending, false},
+		{"unknown", StatusPending, false},
+	}
+	for _, tt := range tests {
+		if got := canTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("canTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestIsTerminalStatus(t *testing.T) {
+	for status, want := range map[string]bool{
+		StatusPending: false, StatusResolved: false,
+		StatusClosed: true, StatusRejected: true, StatusMerged: true,
+		"unknown": false,
+	} {
+		if got := isTerminalStatus(status); got != want {
+			t.Errorf("isTerminalStatus(%s) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestTransitionStatus(t *testing.T) {
+	admin := statusActor{ID: 7, Type: "admin"}
+	tests := []struct {
+		name     string
+		from, to string
+		reason   string
+		changed  int64 // rows the guarded update affects
+		wantErr  error
+	}{
+		{name: "acknowledge", from: StatusPending, to: StatusAcknowledged, changed: 1},
+		{name: "unknown status", from: StatusPending, to: "done", changed: 1, wantErr: errUnknownStatus},
+		{name: "illegal", from: StatusPending, to: StatusClosed, changed: 1, wantErr: errIllegalTransition},
+		{name: "reject needs reason", from: StatusPending, to: StatusRejected, changed: 1, wantErr: errReasonRequired},
+		{name: "reject with reason", from: StatusPending, to: StatusRejected, reason: "spam", changed: 1},
+		{name: "lost race", from: StatusPending, to: StatusAcknowledged, changed: 0, wantErr: errConcurrentChange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.execRows = tt.changed
+			complaint := Complaint{ID: 1, Status: tt.from, Version: 3}
+
+			err := transitionStatus(db, &complaint, tt.to, admin, tt.reason)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if complaint.Status != tt.from || complaint.Version != 3 {
+					t.Errorf("complaint changed on error: %s v%d", complaint.Status, complaint.Version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if complaint.Status != tt.to || complaint.Version != 4 {
+				t.Errorf("complaint = %s v%d, want %s v4", complaint.Status, complaint.Version, tt.to)
+			}
+			if !stub.ran(`INSERT INTO "complaint_status_histories"`) {
+				t.Error("no history row written")
+			}
+			if !stub.ran(`INSERT INTO "outbox_events"`) {
+				t.Error("no status_changed event enqueued")
+			}
+		})
+	}
+}
+
+func TestStatusErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errUnknownStatus, 400},
+		{errReasonRequired, 400},
+		{errIllegalTransition, 409},
+		{errConcurrentChange, 409},
+		{errors.New("db down"), 500},
+	}
+	for _, tt := range tests {
+		if got := statusErrorCode(tt.err); got != tt.want {
+			t.Errorf("statusErrorCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestTransitionStatusConfirmation(t *testing.T) {
+	t.Setenv("CONFIRMATION_WINDOW", "48h")
+	citizen := statusActor{ID: 5, Type: "citizen"}
+	due := time.Now().Add(time.Hour)
+	tests := []struct {
+		name        string
+		from, to    string
+		actor       statusActor
+		wantErr     error
+		wantDue     bool // confirmation_due_at set ~48h out
+		wantReopens int
+		wantResolve bool // resolve_due_at restamped from the SLA policy
+	}{
+		{name: "resolving opens the window", from: StatusInProgress, to: StatusResolved, actor: statusActor{ID: 7, Type: "admin"}, wantDue: true},
+		{name: "staff cannot close", from: StatusResolved, to: StatusClosed, actor: statusActor{ID: 7, Type: "admin"}, wantErr: errCitizenConfirms},
+		{name: "citizen closes", from: StatusResolved, to: StatusClosed, actor: citizen},
+		{name: "auto-close", from: StatusResolved, to: StatusClosed, actor: systemActor},
+		{name: "citizen reopens", from: StatusResolved, to: StatusReopened, actor: citizen, wantReopens: 2, wantResolve: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.returns(`FROM "sla_policies"`,
+				[]string{"id", "government_id", "acknowledge_minutes", "resolve_minutes"},
+				[]driver.Value{int64(1), int64(2), int64(60), int64(24 * 60)})
+			overdue := time.Now().Add(-72 * time.Hour)
+			complaint := Complaint{ID: 1, GovernmentID: 2, Status: tt.from, Version: 3, ReopenCount: 1, ResolveDueAt: &overdue}
+			if tt.from == StatusResolved {
+				complaint.ConfirmationDueAt = &due
+			}
+
+			err := transitionStatus(db, &complaint, tt.to, tt.actor, "")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if complaint.ConfirmationDueAt != &due {
+					t.Error("confirmation_due_at changed on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			switch {
+			case tt.wantDue:
+				if complaint.ConfirmationDueAt == nil || time.Until(*complaint.ConfirmationDueAt).Round(time.Hour) != 48*time.Hour {
+					t.Errorf("confirmation_due_at = %v, want ~48h from now", complaint.ConfirmationDueAt)
+				}
+			case complaint.ConfirmationDueAt != nil:
+				t.Errorf("confirmation_due_at = %v, want cleared", complaint.ConfirmationDueAt)
+			}
+			if want := max(tt.wantReopens, 1); complaint.ReopenCount != want {
+				t.Errorf("reopen_count = %d, want %d", complaint.ReopenCount, want)
+			}
+			restamped := complaint.ResolveDueAt != &overdue
+			if restamped != tt.wantResolve {
+				t.Errorf("resolve_due_at restamped = %v, want %v", restamped, tt.wantResolve)
+			}
+			if tt.wantResolve && time.Until(*complaint.ResolveDueAt).Round(time.Hour) != 24*time.Hour {
+				t.Errorf("resolve_due_at = %v, want ~24h from now", complaint.ResolveDueAt)
+			}
+		})
+	}
+}
+
+func TestConfirmationErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errComplaintNotFound, 404},
+		{errNotComplaintOwner, 403},
+		{fmt.Errorf("%w: /complaints/media/x", errForeignMedia), 422},
+		{errNotAwaitingResponse, 409},
+		{errConfirmationExpired, 409},
+		{errCitizenConfirms, 409},
+		{errConcurrentChange, 409},
+		{errors.New("db down"), 500},
+	}
+	for _, tt := range tests {
+		if got := confirmationErrorCode(tt.err); got != tt.want {
+			t.Errorf("confirmationErrorCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateComplaintVersioned(t *testing.T) {
+	tests := []struct {
+		name    string
+		changed int64
+		wantErr error
+		wantVer int
+	}{
+		{name: "current version", changed: 1, wantVer: 4},
+		{name: "changed meanwhile", changed: 0, wantErr: errConcurrentChange, wantVer: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.execRows = tt.changed
+			complaint := Complaint{ID: 1, Version: 3, Description: "edited"}
+			err := updateComplaintVersioned(db, &complaint, map[string]interface{}{"description": complaint.Description})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if complaint.Version != tt.wantVer {
+				t.Errorf("version = %d, want %d", complaint.Version, tt.wantVer)
+			}
+			if !stub.ran("AND version = ") {
+				t.Error("update is not guarded on the version")
+			}
+			if stub.ran(`"upvotes"`) || stub.ran(`"status"`) {
+				t.Error("update rewrites columns it did not change")
+			}
+		})
+	}
+}
@@ -3,23 +3,38 @@
 // =============================================================================
 // Connects to: PostgreSQL (complaint_db + PostGIS), RabbitMQ, Redis, MinIO
 // Port: 8083
+// Auth: JWTs issued by admin-service (citizen + admin claims, shared secret)
 //
-// Domains: Complaints (geo-tagged, multi-image), Upvote/Downvote,
-//          Comments, Actions Taken (completion tracking, auto-resolve),
-//          Priority Scoring, Nearby Search
+// Domains: Complaints (geo-tagged, multi-image), Status Lifecycle + History,
+//          SLA Deadlines + Breach Sweeper, Duplicate Detection + Merge,
+//          Votes (±1, retract/switch), Comments (threads, edits, official
+//          responses), Actions Taken (completion tracking, auto-resolve),
+//          AI Analysis Results, Transactional Outbox,
+//          Dashboard Counters (Redis), Priority Scoring, Nearby Search,
+//          Map Feeds (GeoJSON + vector tiles, clusters, heatmap),
+//          Jurisdiction Boundaries (point-in-polygon government + ward),
+//          Full-Text Search (complaints + comments), Export (CSV/XLSX/GeoJSON),
+//          Bulk CSV Import (dry run, resumable jobs), Open311 GeoReport v2,
+//          Media (sanitised renditions, presigned uploads, private bucket),
+//          Photo Evidence (EXIF GPS/time vs complaint location),
+//          Image Reuse Detection (perceptual hash),
+//          Moderation (reports, content filter, queue, bans, audit trail),
+//          Citizen Confirmation (rate or reopen, auto-close, dept metrics),
+//          Resolution Approval (per-government sign-off, after photos)
+//
+// Commands: ./complaint-service reconcile-dashboard  (rebuild Redis counters)
 // =============================================================================
 
 package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -41,7 +56,7 @@ type Complaint struct {
 	Category       string    `gorm:"not null" json:"category"`
 	Description    string    `gorm:"type:text;not null" json:"description"`
 	MultimediaURLs string    `gorm:"type:text" json:"multimedia_urls,omitempty"` // JSON array of image URLs
-	Status         string    `gorm:"default:pending" json:"status"`              // pending | in_progress | resolved | rejected
+	Status         string    `gorm:"default:pending" json:"status"`              // lifecycle: see statusTransitions
 	Upvotes        int       `gorm:"default:0" json:"upvotes"`
 	Downvotes      int       `gorm:"default:0" json:"downvotes"`
 	Latitude       float64   `json:"latitude"`
@@ -51,6 +66,46 @@ type Complaint struct {
 	AIAnalysis     string    `gorm:"type:text" json:"ai_analysis,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+
+	// SLA deadlines, stamped at creation and on reassignment (see sla.go)
+	SLAPolicyID           *uint      `json:"sla_policy_id,omitempty"`
+	AcknowledgeDueAt      *time.Time `gorm:"index" json:"acknowledge_due_at,omitempty"`
+	ResolveDueAt          *time.Time `gorm:"index" json:"resolve_due_at,omitempty"`
+	AcknowledgeBreachedAt *time.Time `json:"acknowledge_breached_at,omitempty"`
+	ResolveBreachedAt     *time.Time `json:"resolve_breached_at,omitempty"`
+
+	// Set when folded into a canonical complaint (see merge.go)
+	MergedIntoID *uint      `gorm:"index" json:"merged_into_id,omitempty"`
+	MergedAt     *time.Time `json:"merged_at,omitempty"`
+
+	// Resolved from the location on creation (see jurisdiction.go)
+	WardID *uint  `gorm:"index" json:"ward_id,omitempty"`
+	Ward   string `json:"ward,omitempty"`
+
+	// Legacy records brought in by a CSV import (see imports.go)
+	ImportJobID *uint  `gorm:"index" json:"import_job_id,omitempty"`
+	ExternalRef string `json:"external_ref,omitempty"`
+
+	// Set when filed through the Open311 API (see open311.go)
+	Open311KeyID     *uint  `json:"open311_key_id,omitempty"`
+	ExternalMediaURL string `json:"external_media_url,omitempty"` // client's media_url, never fetched
+
+	// Photo EXIF checked against the location on creation (see evidence.go)
+	PhotoVerification string     `gorm:"index" json:"photo_verification,omitempty"`
+	PhotoDistanceM    *float64   `json:"photo_distance_m,omitempty"`
+	PhotoTakenAt      *time.Time `json:"photo_taken_at,omitempty"`
+	LocationFromPhoto bool       `gorm:"default:false" json:"location_from_photo,omitempty"`
+
+	// A photo near-identical to another complaint's (see imagehash.go)
+	ReusedImage bool `gorm:"default:false;index" json:"reused_image,omitempty"`
+
+	// visible | held | hidden (see moderation.go)
+	ModerationStatus string `gorm:"default:visible;index" json:"moderation_status"`
+
+	// Citizen confirmation after resolution (see confirmation.go)
+	ConfirmationDueAt  *time.Time `gorm:"index" json:"confirmation_due_at,omitempty"`
+	SatisfactionRating *int       `json:"satisfaction_rating,omitempty"`
+	ReopenCount        int        `gorm:"default:0" json:"reopen_count"`
 }
 
 // Priority score = upvotes - (downvotes * 2)
@@ -58,24 +113,24 @@ func (c *Complaint) PriorityScore() int {
 	return c.Upvotes - (c.Downvotes * 2)
 }
 
-type ComplaintUpvote struct {
-	ID          uint `gorm:"primaryKey" json:"id"`
-	ComplaintID uint `gorm:"not null" json:"complaint_id"`
-	UserID      uint `gorm:"not null" json:"user_id"`
-}
-
-type ComplaintDownvote struct {
-	ID          uint `gorm:"primaryKey" json:"id"`
-	ComplaintID uint `gorm:"not null" json:"complaint_id"`
-	UserID      uint `gorm:"not null" json:"user_id"`
-}
-
 type ComplaintComment struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	ComplaintID uint      `gorm:"index;not null" json:"complaint_id"`
-	UserID      uint      `gorm:"not null" json:"user_id"`
+	UserID      uint      `gorm:"not null" json:"user_id"` // 0 for staff comments
 	Content     string    `gorm:"type:text;not null" json:"content"`
 	CreatedAt   time.Time `json:"created_at"`
+
+	// Threads, official responses and author edits (see comments.go)
+	ParentID  *uint               `gorm:"index" json:"parent_id,omitempty"`
+	RootID    *uint               `gorm:"index" json:"root_id,omitempty"`
+	AdminID   *uint               `json:"admin_id,omitempty"`
+	Official  bool                `gorm:"default:false" json:"official"`
+	EditedAt  *time.Time          `json:"edited_at,omitempty"`
+	DeletedAt *time.Time          `json:"deleted_at,omitempty"`
+	Replies   []*ComplaintComment `gorm:"-" json:"replies,omitempty"`
+
+	// visible | held | hidden (see moderation.go)
+	ModerationStatus string `gorm:"default:visible;index" json:"moderation_status"`
 }
 
 // ActionTaken — government response to a complaint with completion %
@@ -87,6 +142,7 @@ type ActionTaken struct {
 	ActionDetails        string    `gorm:"type:text;not null" json:"action_details"`
 	ActionMultimediaURLs string    `gorm:"type:text" json:"action_multimedia_urls,omitempty"`
 	CompletionPercent    int       `gorm:"default:0" json:"completion_percentage"`
+	AfterImageURL        string    `json:"after_image_url,omitempty"` // required at 100% under approval (see approval.go)
 	CreatedAt            time.Time `json:"created_at"`
 }
 
@@ -131,18 +187,33 @@ func connectPostgres() {
 		log.Fatalf("[complaint-service] PostgreSQL connection failed: %v", err)
 	}
 
-	// Enable PostGIS
+	// Enable PostGIS + trigram similarity (duplicate detection)
 	sqlDB, _ := db.DB()
 	sqlDB.Exec("CREATE EXTENSION IF NOT EXISTS postgis")
+	sqlDB.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm")
 
 	db.AutoMigrate(
-		&Complaint{}, &ComplaintUpvote{}, &ComplaintDownvote{},
-		&ComplaintComment{}, &ActionTaken{},
+		&Complaint{}, &ComplaintVote{},
+		&ComplaintComment{}, &ComplaintCommentEdit{}, &ActionTaken{}, &ComplaintStatusHistory{},
+		&SLAPolicy{}, &ComplaintAnalysis{}, &AnalysisDecision{},
+		&OutboxEvent{}, &JurisdictionBoundary{}, &ImportJob{}, &ImportRowError{},
+		&Open311APIKey{}, &PendingUpload{}, &MediaObject{}, &ImageMatch{},
+		&ContentReport{}, &ModerationDecision{}, &UserBan{}, &ComplaintConfirmation{},
+		&ResolutionPolicy{},
 	)
+	migrateLegacyVotes()
+	migrateSearchVectors()
+	migrateLegacyMediaURLs()
+	migrateOpen311Media()
+	migrateBanGovernments()
 
 	// Unique constraints
-	sqlDB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_upvote_unique ON complaint_upvotes(complaint_id, user_id)")
-	sqlDB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_downvote_unique ON complaint_downvotes(complaint_id, user_id)")
+	sqlDB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_sla_policy_unique ON sla_policies(government_id, COALESCE(department_id, 0), LOWER(category))")
+	sqlDB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_complaint_external_ref ON complaints(government_id, external_ref) WHERE external_ref <> ''")
+	sqlDB.Exec("CREATE INDEX IF NOT EXISTS idx_complaint_description_trgm ON complaints USING GIN (description gin_trgm_ops)")
+	sqlDB.Exec("CREATE INDEX IF NOT EXISTS idx_jurisdiction_geom ON jurisdiction_boundaries USING GIST (geom)")
+	sqlDB.Exec("CREATE INDEX IF NOT EXISTS idx_complaint_geom ON complaints USING GIST (" + pointGeomSQL + ")")
+	sqlDB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_report_once ON content_reports(target_type, target_id, reporter_id) WHERE source = 'citizen'")
 
 	log.Println("[complaint-service] ✅ PostgreSQL Connected Successfully (PostGIS enabled)")
 }
@@ -221,6 +292,7 @@ func connectMinIO() {
 	if err != nil {
 		log.Fatalf("[complaint-service] MinIO connection failed: %v", err)
 	}
+	connectMinIOPresigner()
 	log.Println("[complaint-service] ✅ MinIO Connected Successfully")
 }
 
@@ -230,38 +302,41 @@ func healthHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "healthy", "service": "complaint-service"})
 }
 
-// List complaints for a government (priority-sorted)
+// List complaints (priority-sorted, keyset-paginated). Filters: filters.go
 func listComplaintsHandler(c *gin.Context) {
-	govID := c.Query("government_id")
-	govIDs := c.Query("government_ids") // comma-separated: "1,2,3"
-	status := c.Query("status")
-	deptID := c.Query("department_id")
-
-	var complaints []Complaint
-	query := db
-	if govID != "" {
-		query = query.Where("government_id = ?", govID)
-	} else if govIDs != "" {
-		ids := strings.Split(govIDs, ",")
-		validIDs := []string{}
-		for _, id := range ids {
-			id = strings.TrimSpace(id)
-			if id != "" {
-				validIDs = append(validIDs, id)
-			}
-		}
-		if len(validIDs) > 0 {
-			query = query.Where("government_id IN ?", validIDs)
-		}
+	query, err := applyComplaintFilters(c, db.Model(&Complaint{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if status != "" {
-		query = query.Where("status = ?", status)
+	var cursor *listCursor
+	if raw := c.Query("cursor"); raw != "" {
+		if cursor, err = decodeCursor(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
-	if deptID != "" {
-		query = query.Where("department_id = ?", deptID)
+	limit := pageLimit(c)
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	query.Count(&total)
+
+	complaints := []Complaint{}
+	applyCursor(query, cursor).Order(priorityOrder).Limit(limit + 1).Find(&complaints)
+
+	var nextCursor *string
+	if len(complaints) > limit {
+		complaints = complaints[:limit]
+		next := encodeCursor(&complaints[limit-1])
+		nextCursor = &next
 	}
-	query.Order("(upvotes - downvotes * 2) DESC, created_at DESC").Find(&complaints)
-	c.JSON(http.StatusOK, complaints)
+	c.JSON(http.StatusOK, gin.H{
+		"complaints":  complaints,
+		"total":       total,
+		"limit":       limit,
+		"next_cursor": nextCursor,
+	})
 }
 
 func getComplaintHandler(c *gin.Context) {
@@ -271,42 +346,126 @@ func getComplaintHandler(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "complaint not found"})
 		return
 	}
+	if complaint.MergedIntoID != nil {
+		c.JSON(http.StatusOK, mergedPointer(complaint))
+		return
+	}
+	if complaint.ModerationStatus != ModerationVisible && !canSeeModerated(c, &complaint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "complaint is " + complaint.ModerationStatus + " by moderation"})
+		return
+	}
 	c.JSON(http.StatusOK, complaint)
 }
 
+// insertComplaint files a new pending complaint: SLA deadlines, content
+// screening, initial history row, created event and AI analysis job, all in tx.
+func insertComplaint(tx *gorm.DB, complaint *Complaint, actor statusActor) error {
+	complaint.Status = StatusPending
+	complaint.Version = 1
+	stampSLA(tx, complaint, time.Now())
+	held := screenContent(complaint.Description, complaint.ManualLocation)
+	complaint.ModerationStatus = ModerationVisible
+	if len(held) > 0 {
+		complaint.ModerationStatus = ModerationHeld
+	}
+	if err := tx.Create(complaint).Error; err != nil {
+		return err
+	}
+	if len(held) > 0 {
+		if err := recordFilterHold(tx, "complaint", complaint.ID, complaint, held); err != nil {
+			return err
+		}
+	}
+	if err := recordInitialStatus(tx, complaint, actor); err != nil {
+		return err
+	}
+	// Created event + AI analysis job, relayed to RabbitMQ after commit
+	if err := enqueueComplaintEvent(tx, EventComplaintCreated, complaint, nil); err != nil {
+		return err
+	}
+	return enqueueAnalysisJob(tx, complaint)
+}
+
 func createComplaintHandler(c *gin.Context) {
 	var complaint Complaint
 	if err := c.ShouldBindJSON(&complaint); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	complaint.Status = "pending"
-	complaint.Version = 1
-	if err := db.Create(&complaint).Error; err != nil {
+	// Server-owned fields are never taken from the body
+	complaint.ID = 0
+	complaint.UserID = getUserID(c)
+	complaint.Upvotes, complaint.Downvotes = 0, 0
+	complaint.AIAnalysis = ""
+	complaint.MergedIntoID, complaint.MergedAt = nil, nil
+	complaint.WardID, complaint.Ward = nil, ""
+	complaint.ImportJobID, complaint.ExternalRef = nil, ""
+	complaint.Open311KeyID, complaint.ExternalMediaURL = nil, ""
+	complaint.LocationFromPhoto, complaint.ReusedImage = false, false
+
+	// Geotagged photos fill in a missing location before it is resolved
+	media, err := complaintMedia(db, complaint.MultimediaURLs)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := checkMediaOwnership(c, media, 0); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	prefillLocationFromPhotos(&complaint, media)
 
-	// Publish to RabbitMQ for AI analysis
-	if amqpConn != nil {
-		ch, err := amqpConn.Channel()
-		if err == nil {
-			defer ch.Close()
-			body, _ := json.Marshal(map[string]interface{}{
-				"complaint_id":  complaint.ID,
-				"description":   complaint.Description,
-				"category":      complaint.Category,
-				"latitude":      complaint.Latitude,
-				"longitude":     complaint.Longitude,
-				"government_id": complaint.GovernmentID,
-			})
-			ch.Publish("", "complaint_analysis", false, false, amqp.Publishing{
-				ContentType: "application/json",
-				Body:        body,
+	// The location decides where the complaint is filed, not the client
+	match, err := resolveJurisdiction(db, complaint.Latitude, complaint.Longitude)
+	if errors.Is(err, errOutsideJurisdiction) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if match != nil {
+		complaint.GovernmentID = match.GovernmentID
+		complaint.WardID, complaint.Ward = match.WardID, match.Ward
+	}
+	if rejectBanned(c, complaint.GovernmentID) {
+		return
+	}
+
+	// Offer existing complaints first unless the citizen insists (?force=true)
+	if c.Query("force") != "true" {
+		candidates, err := findDuplicateCandidates(duplicateQuery{
+			Category:    complaint.Category,
+			Description: complaint.Description,
+			Latitude:    complaint.Latitude,
+			Longitude:   complaint.Longitude,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(candidates) > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":      "possible duplicate complaints found; upvote one of them or resubmit with ?force=true",
+				"duplicates": candidates,
 			})
+			return
 		}
 	}
 
+	verifyPhotoEvidence(&complaint, media, time.Now())
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := insertComplaint(tx, &complaint, actorFromContext(c)); err != nil {
+			return err
+		}
+		return linkComplaintMedia(tx, &complaint, media)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusCreated, complaint)
 }
 
@@ -322,84 +481,83 @@ func updateComplaintHandler(c *gin.Context) {
 		Description    string `json:"description"`
 		MultimediaURLs string `json:"multimedia_urls"`
 		Status         string `json:"status"`
+		Reason         string `json:"reason"`
 	}
 	if err := c.ShouldBindJSON(&update); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if update.Description != "" {
-		complaint.Description = update.Description
-	}
-	if update.MultimediaURLs != "" {
-		complaint.MultimediaURLs = update.MultimediaURLs
-	}
-	if update.Status != "" {
-		complaint.Status = update.Status
-	}
-	complaint.Version++
-	db.Save(&complaint)
-
-	c.JSON(http.StatusOK, complaint)
-}
-
-// ── Upvote / Downvote ───────────────────────────────────────────────────────
-
-func upvoteHandler(c *gin.Context) {
-	complaintID, _ := strconv.Atoi(c.Param("id"))
-	var body struct {
-		UserID uint `json:"user_id" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	// Citizens may edit their own complaint; status changes are staff-only
+	if isAdmin(c) {
+		if !canManageComplaint(c, &complaint) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "complaint is outside your jurisdiction"})
+			return
+		}
+	} else {
+		if complaint.UserID != getUserID(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not your complaint"})
+			return
+		}
+		if update.Status != "" && update.Status != complaint.Status {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only staff can change complaint status"})
+			return
+		}
 	}
 
-	vote := ComplaintUpvote{ComplaintID: uint(complaintID), UserID: body.UserID}
-	if err := db.Create(&vote).Error; err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "already upvoted"})
-		return
-	}
-	db.Model(&Complaint{}).Where("id = ?", complaintID).Update("upvotes", gorm.Expr("upvotes + 1"))
-	c.JSON(http.StatusOK, gin.H{"message": "upvoted"})
-}
-
-func downvoteHandler(c *gin.Context) {
-	complaintID, _ := strconv.Atoi(c.Param("id"))
-	var body struct {
-		UserID uint `json:"user_id" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Status changes go through the lifecycle state machine
+		if update.Status != "" && update.Status != complaint.Status {
+			if err := transitionStatus(tx, &complaint, update.Status, actorFromContext(c), update.Reason); err != nil {
+				return err
+			}
+		}
+		if update.Description == "" && update.MultimediaURLs == "" {
+			return nil
+		}
+		changes := map[string]interface{}{}
+		if update.Description != "" {
+			complaint.Description = update.Description
+			changes["description"] = complaint.Description
+			if held := screenContent(complaint.Description, complaint.ManualLocation); len(held) > 0 {
+				complaint.ModerationStatus = ModerationHeld
+				changes["moderation_status"] = ModerationHeld
+				if err := recordFilterHold(tx, "complaint", complaint.ID, &complaint, held); err != nil {
+					return err
+				}
+			}
+		}
+		if update.MultimediaURLs != "" {
+			complaint.MultimediaURLs = update.MultimediaURLs
+			changes["multimedia_urls"] = complaint.MultimediaURLs
+			// New photos are judged against the original filing time
+			media, err := complaintMedia(tx, complaint.MultimediaURLs)
+			if err != nil {
+				return err
+			}
+			if err := checkMediaOwnership(c, media, complaint.ID); err != nil {
+				return err
+			}
+			verifyPhotoEvidence(&complaint, media, complaint.CreatedAt)
+			changes["photo_verification"] = complaint.PhotoVerification
+			changes["photo_distance_m"] = complaint.PhotoDistanceM
+			changes["photo_taken_at"] = complaint.PhotoTakenAt
+			if err := linkComplaintMedia(tx, &complaint, media); err != nil {
+				return err
+			}
+		}
+		return updateComplaintVersioned(tx, &complaint, changes)
+	})
+	if errors.Is(err, errForeignMedia) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
-
-	vote := ComplaintDownvote{ComplaintID: uint(complaintID), UserID: body.UserID}
-	if err := db.Create(&vote).Error; err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "already downvoted"})
+	if err != nil {
+		c.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
 		return
 	}
-	db.Model(&Complaint{}).Where("id = ?", complaintID).Update("downvotes", gorm.Expr("downvotes + 1"))
-	c.JSON(http.StatusOK, gin.H{"message": "downvoted"})
-}
-
-// ── Comments ────────────────────────────────────────────────────────────────
 
-func getCommentsHandler(c *gin.Context) {
-	complaintID := c.Param("id")
-	var comments []ComplaintComment
-	db.Where("complaint_id = ?", complaintID).Order("created_at DESC").Find(&comments)
-	c.JSON(http.StatusOK, comments)
-}
-
-func addCommentHandler(c *gin.Context) {
-	var comment ComplaintComment
-	if err := c.ShouldBindJSON(&comment); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	db.Create(&comment)
-	c.JSON(http.StatusCreated, comment)
+	c.JSON(http.StatusOK, complaint)
 }
 
 // ── Actions Taken ───────────────────────────────────────────────────────────
@@ -418,14 +576,52 @@ func addActionHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	action.ComplaintID = uint(complaintID)
-	db.Create(&action)
-
-	// Auto-resolve at 100% completion
-	if action.CompletionPercent >= 100 {
-		db.Model(&Complaint{}).Where("id = ?", complaintID).Update("status", "resolved")
-	} else if action.CompletionPercent > 0 {
-		db.Model(&Complaint{}).Where("id = ?", complaintID).Update("status", "in_progress")
+	var complaint Complaint
+	if err := db.First(&complaint, complaintID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "complaint not found"})
+		return
+	}
+	if !canManageComplaint(c, &complaint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "complaint is outside your jurisdiction"})
+		return
+	}
+	if isTerminalStatus(complaint.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("complaint is %s", complaint.Status)})
+		return
+	}
+	action.ID = 0
+	action.ComplaintID = complaint.ID
+	action.AdminID = getAdminID(c)
+	action.GovernmentID = complaint.GovernmentID
+	needsAfterPhoto := action.CompletionPercent >= 100 && requiresApproval(db, &complaint)
+
+	// Auto-advance: any progress → in_progress, 100% completion → resolved
+	// (or pending_verification when a manager has to sign off)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&action).Error; err != nil {
+			return err
+		}
+		if needsAfterPhoto {
+			if err := claimAfterPhoto(tx, &complaint, &action); err != nil {
+				return err
+			}
+		}
+		if err := advanceForCompletion(tx, &complaint, action.CompletionPercent, actorFromContext(c)); err != nil {
+			return err
+		}
+		return enqueueComplaintEvent(tx, EventComplaintActionAdded, &complaint, map[string]interface{}{
+			"action_id":             action.ID,
+			"admin_id":              action.AdminID,
+			"completion_percentage": action.CompletionPercent,
+		})
+	})
+	if errors.Is(err, errAfterPhoto) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusCreated, action)
@@ -440,15 +636,9 @@ func nearbyComplaintsHandler(c *gin.Context) {
 	category := c.Query("category")
 
 	var complaints []Complaint
-	query := `
-		SELECT * FROM complaints
-		WHERE ST_DWithin(
-			ST_MakePoint(longitude, latitude)::geography,
-			ST_MakePoint(?, ?)::geography,
-			?
-		)
-	`
-	args := []interface{}{lng, lat, radius}
+	query := "SELECT * FROM complaints WHERE " + withinRadiusSQL
+	query += " AND status <> ? AND moderation_status = ?"
+	args := []interface{}{lng, lat, radius, StatusMerged, ModerationVisible}
 	if category != "" {
 		query += " AND LOWER(category) = LOWER(?)"
 		args = append(args, category)
@@ -459,65 +649,6 @@ func nearbyComplaintsHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, complaints)
 }
 
-// ── Image Upload (MinIO) ────────────────────────────────────────────────────
-
-func uploadImageHandler(c *gin.Context) {
-	file, header, err := c.Request.FormFile("image")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no image file provided"})
-		return
-	}
-	defer file.Close()
-
-	bucket := env("MINIO_BUCKET", "civic-complaints")
-	objectName := fmt.Sprintf("complaints/%d_%s", time.Now().UnixNano(), header.Filename)
-
-	_, err = minioClient.PutObject(
-		context.Background(),
-		bucket,
-		objectName,
-		file,
-		header.Size,
-		minio.PutObjectOptions{ContentType: header.Header.Get("Content-Type")},
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	imageURL := fmt.Sprintf("http://%s/%s/%s", env("MINIO_ENDPOINT", "localhost:9000"), bucket, objectName)
-	c.JSON(http.StatusOK, gin.H{"image_url": imageURL})
-}
-
-// Upload action image
-func uploadActionImageHandler(c *gin.Context) {
-	file, header, err := c.Request.FormFile("image")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no image file provided"})
-		return
-	}
-	defer file.Close()
-
-	bucket := env("MINIO_BUCKET", "civic-complaints")
-	objectName := fmt.Sprintf("actions/%d_%s", time.Now().UnixNano(), header.Filename)
-
-	_, err = minioClient.PutObject(
-		context.Background(),
-		bucket,
-		objectName,
-		file,
-		header.Size,
-		minio.PutObjectOptions{ContentType: header.Header.Get("Content-Type")},
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	imageURL := fmt.Sprintf("http://%s/%s/%s", env("MINIO_ENDPOINT", "localhost:9000"), bucket, objectName)
-	c.JSON(http.StatusOK, gin.H{"image_url": imageURL})
-}
-
 // ── Reassignment (for "Others" category) ────────────────────────────────────
 
 func reassignComplaintHandler(c *gin.Context) {
@@ -527,6 +658,10 @@ func reassignComplaintHandler(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "complaint not found"})
 		return
 	}
+	if !canManageComplaint(c, &complaint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "complaint is outside your jurisdiction"})
+		return
+	}
 	var body struct {
 		DepartmentID uint   `json:"department_id" binding:"required"`
 		Category     string `json:"category"`
@@ -535,12 +670,30 @@ func reassignComplaintHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	fromDept, fromCategory := complaint.DepartmentID, complaint.Category
 	complaint.DepartmentID = &body.DepartmentID
 	if body.Category != "" {
 		complaint.Category = body.Category
 	}
-	complaint.Version++
-	db.Save(&complaint)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// New department, new clock
+		stampSLA(tx, &complaint, time.Now())
+		changes := slaColumns(&complaint)
+		changes["department_id"] = complaint.DepartmentID
+		changes["category"] = complaint.Category
+		if err := updateComplaintVersioned(tx, &complaint, changes); err != nil {
+			return err
+		}
+		return enqueueComplaintEvent(tx, EventComplaintReassigned, &complaint, map[string]interface{}{
+			"from_department_id": fromDept,
+			"from_category":      fromCategory,
+			"admin_id":           getAdminID(c),
+		})
+	})
+	if err != nil {
+		c.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, complaint)
 }
 
@@ -549,6 +702,15 @@ func reassignComplaintHandler(c *gin.Context) {
 func main() {
 	log.Println("[complaint-service] Starting Civic Connect Complaint Service...")
 
+	if len(os.Args) > 1 && os.Args[1] == "reconcile-dashboard" {
+		connectPostgres()
+		connectRedis()
+		if err := reconcileDashboard(context.Background()); err != nil {
+			log.Fatalf("[complaint-service] Dashboard reconciliation failed: %v", err)
+		}
+		return
+	}
+
 	connectPostgres()
 	connectRabbitMQ()
 	connectRedis()
@@ -556,37 +718,145 @@ func main() {
 
 	log.Println("[complaint-service] ✅ All connections established – Connected Successfully")
 
+	go runSLASweeper()
+	go runConfirmationSweeper()
+	startAnalysisConsumer()
+	startDashboardConsumer()
+	go runOutboxRelay()
+	go runImportResumer()
+
 	r := gin.Default()
 
+	// ── Public Routes ────────────────────────────────────────────────────
 	r.GET("/health", healthHandler)
-
-	// Complaints CRUD
-	r.GET("/complaints", listComplaintsHandler)
-	r.GET("/complaints/:id", getComplaintHandler)
-	r.POST("/complaints", createComplaintHandler)
-	r.PUT("/complaints/:id", updateComplaintHandler)
-
-	// Voting
-	r.POST("/complaints/:id/upvote", upvoteHandler)
-	r.POST("/complaints/:id/downvote", downvoteHandler)
-
-	// Comments
+	r.GET("/complaints", optionalAuth(), listComplaintsHandler) // tokens widen moderation visibility
+	r.GET("/complaints/:id", optionalAuth(), getComplaintHandler)
+	r.GET("/complaints/:id/history", statusHistoryHandler)
 	r.GET("/complaints/:id/comments", getCommentsHandler)
-	r.POST("/complaints/comments", addCommentHandler)
-
-	// Actions Taken
+	r.GET("/complaints/:id/comments/:comment_id/history", optionalAuth(), commentHistoryHandler)
 	r.GET("/complaints/:id/actions", getActionsHandler)
-	r.POST("/complaints/:id/actions", addActionHandler)
-
-	// Reassignment (Manager only — for "Others" category)
-	r.PUT("/complaints/:id/reassign", reassignComplaintHandler)
-
-	// Nearby Search
 	r.GET("/complaints/nearby", nearbyComplaintsHandler)
+	r.GET("/complaints/search", searchComplaintsHandler)
+	r.GET("/complaints.geojson", complaintsGeoJSONHandler)
+	r.GET("/complaints/tiles/:z/:x/:y", complaintTileHandler) // y carries the .mvt suffix
+	r.GET("/complaints/clusters", complaintClustersHandler)
+	r.GET("/complaints/heatmap", complaintHeatmapHandler)
+	r.GET("/complaints/jurisdictions", listBoundariesHandler)
+	r.GET("/complaints/jurisdictions/resolve", resolveJurisdictionHandler)
+	r.GET("/complaints/media/*object", mediaRedirectHandler) // presigned redirect, bucket is private
+
+	// ── Open311 GeoReport v2 (POST authenticated by api_key) ─────────────
+	r.GET("/complaints/open311/v2/:resource", open311ListHandler)
+	r.POST("/complaints/open311/v2/:resource", open311CreateRequestHandler)
+	r.GET("/complaints/open311/v2/requests/:id", open311GetRequestHandler)
+	r.GET("/complaints/open311/v2/services/:code", open311ServiceDefinitionHandler)
+	r.POST("/complaints/check-duplicates", checkDuplicatesHandler)
+
+	auth := r.Group("/complaints", authMiddleware())
+	{
+		// Complaints CRUD (owner citizen or scoped admin)
+		auth.PUT("/:id", notBanned(), updateComplaintHandler)
+
+		// Comments (citizens, or staff as official responses); posting checks
+		// bans in postComment
+		auth.POST("/:id/comments", addCommentHandler)
+		auth.PUT("/:id/comments/:comment_id", notBanned(), editCommentHandler)
+		auth.DELETE("/:id/comments/:comment_id", deleteCommentHandler)
+
+		// Image Upload
+		auth.POST("/upload", notBanned(), uploadImageHandler)
+		auth.POST("/upload/action", adminRoleRequired("dept_manager"), uploadActionImageHandler)
+		auth.POST("/uploads/presign", notBanned(), presignUploadHandler)
+		auth.POST("/uploads/:id/finalize", finalizeUploadHandler)
+
+		// Filing checks bans once the location has picked the government
+		auth.POST("", citizenRequired(), createComplaintHandler)
+		auth.POST("/comments", citizenRequired(), legacyAddCommentHandler) // deprecated: use POST /complaints/:id/comments
+
+		// ── Citizen Routes ───────────────────────────────────────────────
+		citizen := auth.Group("", citizenRequired(), notBanned())
+		{
+			// Voting
+			citizen.GET("/:id/vote", getVoteHandler)
+			citizen.PUT("/:id/vote", putVoteHandler)
+			citizen.DELETE("/:id/vote", deleteVoteHandler)
+			citizen.POST("/:id/upvote", upvoteHandler)
+			citizen.POST("/:id/downvote", downvoteHandler)
+
+			// Confirm or reopen a resolved complaint
+			citizen.POST("/:id/confirm", confirmResolutionHandler)
+			citizen.POST("/:id/reopen", reopenComplaintHandler)
+
+			// Reports for the moderation queue
+			citizen.POST("/:id/report", reportComplaintHandler)
+			citizen.POST("/:id/comments/:comment_id/report", reportCommentHandler)
+		}
 
-	// Image Upload
-	r.POST("/complaints/upload", uploadImageHandler)
-	r.POST("/complaints/upload/action", uploadActionImageHandler)
+		// ── Staff Routes (scoped to government / department) ─────────────
+		staff := auth.Group("", adminRoleRequired("dept_manager"))
+		{
+			// Status lifecycle
+			staff.PUT("/:id/status", changeStatusHandler)
+
+			// Actions Taken
+			staff.POST("/:id/actions", addActionHandler)
+
+			// AI analysis review
+			staff.GET("/:id/analyses", listAnalysesHandler)
+			staff.POST("/:id/analyses/:analysis_id/decision", analysisDecisionHandler)
+
+			// Merge duplicates
+			staff.POST("/:id/merge", mergeComplaintsHandler)
+
+			// Reassignment (Manager only — for "Others" category)
+			staff.PUT("/:id/reassign", adminRoleRequired("manager"), reassignComplaintHandler)
+
+			// Manager sign-off before resolution
+			staff.GET("/resolution-policy", getResolutionPolicyHandler)
+			staff.PUT("/resolution-policy", adminRoleRequired("manager"), putResolutionPolicyHandler)
+			staff.POST("/:id/resolution/approve", adminRoleRequired("manager"), approveResolutionHandler)
+			staff.POST("/:id/resolution/reject", adminRoleRequired("manager"), rejectResolutionHandler)
+
+			// SLA policies
+			staff.GET("/sla/policies", listSLAPoliciesHandler)
+			staff.POST("/sla/policies", adminRoleRequired("manager"), createSLAPolicyHandler)
+			staff.PUT("/sla/policies/:id", adminRoleRequired("manager"), updateSLAPolicyHandler)
+			staff.DELETE("/sla/policies/:id", adminRoleRequired("manager"), deleteSLAPolicyHandler)
+
+			// Reports export (Manager+, streamed)
+			staff.GET("/export", adminRoleRequired("manager"), exportComplaintsHandler)
+
+			// Confirmation outcomes, reopen rates and ratings per department
+			staff.GET("/metrics/departments", departmentMetricsHandler)
+
+			// Moderation queue, decisions (audit trail) and bans
+			staff.GET("/moderation/queue", adminRoleRequired("manager"), moderationQueueHandler)
+			staff.POST("/moderation/decisions", adminRoleRequired("manager"), moderationDecisionHandler)
+			staff.GET("/moderation/decisions", adminRoleRequired("manager"), listModerationDecisionsHandler)
+			staff.GET("/moderation/bans", adminRoleRequired("manager"), listBansHandler)
+			staff.DELETE("/moderation/bans/:id", adminRoleRequired("manager"), liftBanHandler)
+
+			// Suspected reused photos (perceptual hash matches)
+			staff.GET("/image-reuse", listImageReuseHandler)
+
+			// Bulk CSV import (Manager+)
+			staff.POST("/imports", adminRoleRequired("manager"), createImportHandler)
+			staff.GET("/imports", adminRoleRequired("manager"), listImportsHandler)
+			staff.GET("/imports/:id", adminRoleRequired("manager"), getImportHandler)
+			staff.GET("/imports/:id/errors", adminRoleRequired("manager"), importErrorsHandler)
+			staff.POST("/imports/:id/resume", adminRoleRequired("manager"), resumeImportHandler)
+			staff.POST("/imports/:id/commit", adminRoleRequired("manager"), commitImportHandler)
+
+			// Open311 API keys (Manager+)
+			staff.POST("/open311/keys", adminRoleRequired("manager"), createAPIKeyHandler)
+			staff.GET("/open311/keys", adminRoleRequired("manager"), listAPIKeysHandler)
+			staff.DELETE("/open311/keys/:id", adminRoleRequired("manager"), revokeAPIKeyHandler)
+
+			// Jurisdiction boundaries (Super Admin only)
+			staff.POST("/jurisdictions", adminRoleRequired("super_admin"), uploadBoundariesHandler)
+			staff.DELETE("/jurisdictions/:id", adminRoleRequired("super_admin"), deleteBoundaryHandler)
+		}
+	}
 
 	port := env("PORT", "8083")
 	log.Printf("[complaint-service] Listening on :%s\n", port)
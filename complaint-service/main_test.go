@@ -0,0 +1,67 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestContext builds a request context with the given JWT claims already
+// set, as authMiddleware would leave them.
+func newTestContext(method, target, body string, claims map[string]interface{}) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	for k, v := range claims {
+		c.Set(k, v)
+	}
+	return c, w
+}
+
+var superAdminClaims = map[string]interface{}{"admin_id": float64(1), "admin_role": "super_admin", "government_id": float64(2)}
+
+// storedComplaint makes complaint #1 of government 2 loadable.
+func storedComplaint(stub *stubDriver, status string, version int) {
+	stub.returns(`FROM "complaints"`,
+		[]string{"id", "government_id", "user_id", "category", "status", "version"},
+		[]driver.Value{int64(1), int64(2), int64(5), "Others", status, int64(version)})
+}
+
+func TestReassignComplaintHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		changed  int64
+		wantCode int
+	}{
+		{name: "reassigned", changed: 1, wantCode: http.StatusOK},
+		{name: "complaint changed meanwhile", changed: 0, wantCode: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			storedComplaint(stub, StatusInProgress, 3)
+			stub.execRows = tt.changed
+			c, w := newTestContext(http.MethodPut, "/complaints/1/reassign", `{"department_id":4,"category":"Roads"}`, superAdminClaims)
+			c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+			reassignComplaintHandler(c)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if !stub.ran("AND version = ") {
+				t.Error("reassignment is not guarded on the version")
+			}
+			if stub.ran(`"upvotes"`) || stub.ran(`"moderation_status"`) {
+				t.Error("reassignment rewrites columns it does not own")
+			}
+			if published := stub.ran(`INSERT INTO "outbox_events"`); published != (tt.wantCode == http.StatusOK) {
+				t.Errorf("reassigned event enqueued = %v", published)
+			}
+		})
+	}
+}
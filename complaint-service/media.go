@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+// ── Image Upload Pipeline ───────────────────────────────────────────────────
+//
+// Uploads are never stored as sent. The bytes are sniffed (JPEG, PNG, WebP
+// only — the client Content-Type and filename are ignored), dimensions are
+// checked from the header before decoding, and the decoded image is
+// re-encoded, which drops EXIF/XMP metadata (GPS, device serials). EXIF
+// orientation is applied first so stripped photos are not rotated, and the
+// GPS fix and capture time are kept on the MediaObject row (evidence.go).
+// Every upload gets a random object prefix holding original, medium and thumb.
+//
+//   complaints/2026/10/<random>/original.jpg | medium.jpg | thumb.jpg
+
+var (
+	errUnsupportedImage = errors.New("unsupported image type (JPEG, PNG or WebP only)")
+	errImageTooLarge    = errors.New("image file is too large")
+	errImageDimensions  = errors.New("image dimensions are out of range")
+)
+
+var allowedImageTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/webp": true}
+
+type imageRendition struct {
+	Name    string
+	MaxSide int
+	Quality int
+}
+
+var imageRenditions = []imageRendition{
+	{Name: "medium", MaxSide: 1280, Quality: 85},
+	{Name: "thumb", MaxSide: 320, Quality: 80},
+}
+
+// StoredImage — what an upload returns; image_url is the sanitised original.
+type StoredImage struct {
+	ImageURL     string `json:"image_url"`
+	MediumURL    string `json:"medium_url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ObjectName   string `json:"object_name"`
+	ContentType  string `json:"content_type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Size         int    `json:"size"`
+
+	EXIF          *photoEvidence `json:"exif,omitempty"`
+	SimilarImages []similarImage `json:"similar_images,omitempty"` // see imagehash.go
+}
+
+// MediaObject — one stored upload, keyed by its object prefix
+type MediaObject struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Prefix      string `gorm:"uniqueIndex;not null" json:"prefix"`
+	ObjectName  string `gorm:"not null" json:"object_name"`
+	Kind        string `gorm:"not null" json:"kind"` // complaints | actions
+	OwnerType   string `gorm:"not null" json:"owner_type"`
+	OwnerID     uint   `gorm:"not null" json:"owner_id"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int    `json:"size"`
+
+	// Set once a complaint references the upload; PHash is its dHash
+	ComplaintID *uint  `gorm:"index" json:"complaint_id,omitempty"`
+	PHash       *int64 `json:"phash,omitempty"`
+
+	// Staff uploads: the uploader's government, and the action that used
+	// the image as its after photo (see approval.go)
+	GovernmentID *uint `gorm:"index" json:"government_id,omitempty"`
+	ActionID     *uint `gorm:"index" json:"action_id,omitempty"`
+
+	// From the upload's EXIF, before it was stripped
+	ExifLatitude  *float64   `json:"exif_latitude,omitempty"`
+	ExifLongitude *float64   `json:"exif_longitude,omitempty"`
+	ExifTakenAt   *time.Time `json:"exif_taken_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func envInt(key string, fallback int) int {
+	n, err := strconv.Atoi(env(key, strconv.Itoa(fallback)))
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(env(key, fallback.String()))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+func mediaBucket() string {
+	return env("MINIO_BUCKET", "civic-complaints")
+}
+
+// mediaURL is the stable client-facing URL of a stored object; it redirects
+// to a short-lived presigned URL (see presign.go).
+func mediaURL(objectName string) string {
+	return strings.TrimSuffix(env("MEDIA_BASE_URL", "/api/v1/complaints/media"), "/") + "/" + objectName
+}
+
+// newMediaPrefix returns a server-generated, unguessable object prefix.
+func newMediaPrefix(kind string) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/%s", kind, time.Now().UTC().Format("2006/01"), hex.EncodeToString(raw)), nil
+}
+
+// decodedImage is a validated upload, decoded with orientation applied.
+type decodedImage struct {
+	Image       image.Image
+	ContentType string
+	Evidence    *photoEvidence
+}
+
+// decodeUpload sniffs and bounds-checks raw bytes, then decodes them.
+func decodeUpload(data []byte) (*decodedImage, error) {
+	contentType := http.DetectContentType(data)
+	if !allowedImageTypes[contentType] {
+		return nil, errUnsupportedImage
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUnsupportedImage, err)
+	}
+	minSide, maxSide := envInt("UPLOAD_MIN_DIMENSION", 64), envInt("UPLOAD_MAX_DIMENSION", 8000)
+	if cfg.Width < minSide || cfg.Height < minSide || cfg.Width > maxSide || cfg.Height > maxSide ||
+		cfg.Width*cfg.Height > envInt("UPLOAD_MAX_PIXELS", 40_000_000) {
+		return nil, fmt.Errorf("%w: %dx%d (allowed %d–%d px per side)", errImageDimensions, cfg.Width, cfg.Height, minSide, maxSide)
+	}
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUnsupportedImage, err)
+	}
+	return &decodedImage{Image: img, ContentType: contentType, Evidence: readPhotoEvidence(data, contentType)}, nil
+}
+
+// encodeImage re-encodes without metadata: PNG stays PNG (transparency),
+// everything else becomes JPEG.
+func encodeImage(img image.Image, asPNG bool, quality int) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if asPNG {
+		err := png.Encode(&buf, img)
+		return buf.Bytes(), "image/png", err
+	}
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	return buf.Bytes(), "image/jpeg", err
+}
+
+func putMediaObject(ctx context.Context, objectName string, data []byte, contentType string) error {
+	_, err := minioClient.PutObject(ctx, mediaBucket(), objectName, bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: contentType, CacheControl: "public, max-age=31536000, immutable"})
+	return err
+}
+
+// storeImage writes the sanitised original and its renditions under a new
+// prefix of kind ("complaints" | "actions").
+func storeImage(ctx context.Context, kind string, decoded *decodedImage) (*StoredImage, error) {
+	prefix, err := newMediaPrefix(kind)
+	if err != nil {
+		return nil, err
+	}
+	asPNG := decoded.ContentType == "image/png"
+	original, contentType, err := encodeImage(decoded.Image, asPNG, 90)
+	if err != nil {
+		return nil, err
+	}
+	ext := map[bool]string{true: "png", false: "jpg"}[asPNG]
+	bounds := decoded.Image.Bounds()
+	stored := &StoredImage{
+		ObjectName:  fmt.Sprintf("%s/original.%s", prefix, ext),
+		ContentType: contentType,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Size:        len(original),
+		EXIF:        decoded.Evidence,
+	}
+	if err := putMediaObject(ctx, stored.ObjectName, original, contentType); err != nil {
+		return nil, err
+	}
+	stored.ImageURL = mediaURL(stored.ObjectName)
+
+	for _, r := range imageRenditions {
+		// Fit never upscales; small images get a re-encoded copy
+		resized := imaging.Fit(decoded.Image, r.MaxSide, r.MaxSide, imaging.Lanczos)
+		data, contentType, err := encodeImage(resized, false, r.Quality)
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("%s/%s.jpg", prefix, r.Name)
+		if err := putMediaObject(ctx, name, data, contentType); err != nil {
+			return nil, err
+		}
+		switch r.Name {
+		case "medium":
+			stored.MediumURL = mediaURL(name)
+		case "thumb":
+			stored.ThumbnailURL = mediaURL(name)
+		}
+	}
+	return stored, nil
+}
+
+// recordMediaObject remembers who stored an upload, what its EXIF said and
+// its perceptual hash. Complaint photos also get their near-identical
+// matches attached to the response.
+func recordMediaObject(c *gin.Context, kind string, stored *StoredImage, decoded *decodedImage) error {
+	ownerType, ownerID := uploadOwner(c)
+	media := MediaObject{
+		Prefix:      path.Dir(stored.ObjectName),
+		ObjectName:  stored.ObjectName,
+		Kind:        kind,
+		OwnerType:   ownerType,
+		OwnerID:     ownerID,
+		ContentType: stored.ContentType,
+		Width:       stored.Width,
+		Height:      stored.Height,
+		Size:        stored.Size,
+		PHash:       perceptualHash(decoded.Image),
+	}
+	if e := stored.EXIF; e != nil {
+		media.ExifLatitude, media.ExifLongitude, media.ExifTakenAt = e.Latitude, e.Longitude, e.TakenAt
+	}
+	if govID := getGovID(c); isAdmin(c) && govID != 0 {
+		media.GovernmentID = &govID
+	}
+	if err := db.Create(&media).Error; err != nil {
+		return err
+	}
+	if kind != "complaints" || media.PHash == nil {
+		return nil
+	}
+	similar, err := findSimilarImages(db, *media.PHash, 0)
+	stored.SimilarImages = similar
+	return err
+}
+
+func uploadErrorCode(err error) int {
+	switch {
+	case errors.Is(err, errImageTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errUnsupportedImage):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, errImageDimensions):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// readImageForm reads the "image" form file, enforcing UPLOAD_MAX_BYTES.
+func readImageForm(c *gin.Context) ([]byte, error) {
+	maxBytes := envInt("UPLOAD_MAX_BYTES", 10<<20)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxBytes)+64<<10)
+	file, header, err := c.Request.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, errImageTooLarge
+		}
+		return nil, errors.New("no image file provided")
+	}
+	defer file.Close()
+	if header.Size > int64(maxBytes) {
+		return nil, errImageTooLarge
+	}
+	return io.ReadAll(io.LimitReader(file, int64(maxBytes)))
+}
+
+func handleImageUpload(c *gin.Context, kind string) {
+	data, err := readImageForm(c)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, errImageTooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	decoded, err := decodeUpload(data)
+	if err != nil {
+		c.JSON(uploadErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	stored, err := storeImage(c.Request.Context(), kind, decoded)
+	if err == nil {
+		err = recordMediaObject(c, kind, stored, decoded)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// POST /complaints/upload
+func uploadImageHandler(c *gin.Context) {
+	handleImageUpload(c, "complaints")
+}
+
+// POST /complaints/upload/action
+func uploadActionImageHandler(c *gin.Context) {
+	handleImageUpload(c, "actions")
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func encodedScene(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	img := testScene(w, h)
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "png":
+		err = png.Encode(&buf, img)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeUpload(t *testing.T) {
+	jpg := encodedScene(t, "jpeg", 200, 120)
+	tests := []struct {
+		name     string
+		data     []byte
+		env      map[string]string
+		wantType string
+		wantErr  error
+	}{
+		{name: "jpeg", data: jpg, wantType: "image/jpeg"},
+		{name: "png", data: encodedScene(t, "png", 120, 200), wantType: "image/png"},
+		{name: "gif", data: encodedScene(t, "gif", 120, 120), wantErr: errUnsupportedImage},
+		{name: "not an image", data: []byte("<html><script>alert(1)</script></html>"), wantErr: errUnsupportedImage},
+		{name: "truncated", data: jpg[:len(jpg)/2], wantErr: errUnsupportedImage},
+		{name: "too small", data: encodedScene(t, "jpeg", 200, 40), wantErr: errImageDimensions},
+		{name: "too wide", data: jpg, env: map[string]string{"UPLOAD_MAX_DIMENSION": "150"}, wantErr: errImageDimensions},
+		{name: "too many pixels", data: jpg, env: map[string]string{"UPLOAD_MAX_PIXELS": "20000"}, wantErr: errImageDimensions},
+		{name: "raised limits", data: encodedScene(t, "jpeg", 200, 40), env: map[string]string{"UPLOAD_MIN_DIMENSION": "32"}, wantType: "image/jpeg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			decoded, err := decodeUpload(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && decoded.ContentType != tt.wantType {
+				t.Errorf("content type = %s, want %s", decoded.ContentType, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestEncodeImage(t *testing.T) {
+	img := testScene(80, 80)
+	for _, asPNG := range []bool{false, true} {
+		data, contentType, err := encodeImage(img, asPNG, 85)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sniffed := http.DetectContentType(data); sniffed != contentType {
+			t.Errorf("asPNG=%v: encoded %s, reported %s", asPNG, sniffed, contentType)
+		}
+		if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+			t.Errorf("asPNG=%v: %v", asPNG, err)
+		}
+	}
+}
+
+func TestUploadErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errImageTooLarge, http.StatusRequestEntityTooLarge},
+		{fmt.Errorf("%w: bad header", errUnsupportedImage), http.StatusUnsupportedMediaType},
+		{fmt.Errorf("%w: 10x10", errImageDimensions), http.StatusUnprocessableEntity},
+		{errors.New("minio down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := uploadErrorCode(tt.err); got != tt.want {
+			t.Errorf("uploadErrorCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestReadImageForm(t *testing.T) {
+	t.Setenv("UPLOAD_MAX_BYTES", "1000")
+	tests := []struct {
+		name    string
+		field   string
+		size    int
+		wantErr error
+	}{
+		{name: "within limit", field: "image", size: 1000},
+		{name: "over limit", field: "image", size: 1001, wantErr: errImageTooLarge},
+		{name: "far over limit", field: "image", size: 200 << 10, wantErr: errImageTooLarge},
+		{name: "wrong field", field: "file", size: 10, wantErr: errors.New("no image file provided")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, _ := form.CreateFormFile(tt.field, "photo.jpg")
+			part.Write(bytes.Repeat([]byte{0xff}, tt.size))
+			form.Close()
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/complaints/upload", &body)
+			c.Request.Header.Set("Content-Type", form.FormDataContentType())
+
+			data, err := readImageForm(c)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != nil && (err == nil || err.Error() != tt.wantErr.Error()):
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			case tt.wantErr == nil && len(data) != tt.size:
+				t.Errorf("read %d bytes, want %d", len(data), tt.size)
+			}
+		})
+	}
+}
+
+func TestNewMediaPrefix(t *testing.T) {
+	a, err := newMediaPrefix("complaints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := newMediaPrefix("complaints")
+	if !regexp.MustCompile(`^complaints/\d{4}/\d{2}/[0-9a-f]{32}$`).MatchString(a) || a == b {
+		t.Errorf("prefixes %q, %q", a, b)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ── Merge Duplicates ────────────────────────────────────────────────────────
+//
+// Children are folded into a canonical parent: votes move (one per user),
+// comments and actions are re-pointed, counters are recomputed and each child
+// transitions to "merged" with merged_into_id pointing at the parent.
+
+var (
+	errMergeConflict  = errors.New("cannot merge")
+	errMergeForbidden = errors.New("complaint is outside your jurisdiction")
+)
+
+func mergeComplaints(tx *gorm.DB, parentID uint, childIDs []uint, actor statusActor, allowed func(*Complaint) bool) (*Complaint, error) {
+	var parent Complaint
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&parent, parentID).Error; err != nil {
+		return nil, err
+	}
+	if !allowed(&parent) {
+		return nil, errMergeForbidden
+	}
+	if parent.MergedIntoID != nil || isTerminalStatus(parent.Status) {
+		return nil, fmt.Errorf("%w: parent complaint is %s", errMergeConflict, parent.Status)
+	}
+
+	seen := map[uint]bool{}
+	unique := childIDs[:0:0]
+	for _, id := range childIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	childIDs = unique
+
+	var children []Complaint
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", childIDs).Find(&children).Error; err != nil {
+		return nil, err
+	}
+	if len(children) != len(childIDs) {
+		return nil, fmt.Errorf("%w: some child complaints do not exist", errMergeConflict)
+	}
+	for _, child := range children {
+		switch {
+		case !allowed(&child):
+			return nil, errMergeForbidden
+		case child.ID == parent.ID:
+			return nil, fmt.Errorf("%w: a complaint cannot be merged into itself", errMergeConflict)
+		case child.GovernmentID != parent.GovernmentID:
+			return nil, fmt.Errorf("%w: complaint %d belongs to another government", errMergeConflict, child.ID)
+		case !canTransition(child.Status, StatusMerged):
+			return nil, fmt.Errorf("%w: complaint %d is %s", errMergeConflict, child.ID, child.Status)
+		}
+	}
+
+	// Votes: one per user on the parent. An existing parent vote wins,
+	// otherwise the user's most recent vote on any child.
+	steps := []struct {
+		sql  string
+		args []interface{}
+	}{
+		{`INSERT INTO complaint_votes (complaint_id, user_id, value, created_at, updated_at)
+			SELECT DISTINCT ON (user_id) ?::bigint, user_id, value, created_at, NOW()
+			FROM complaint_votes WHERE complaint_id IN ?
+			ORDER BY user_id, updated_at DESC
+			ON CONFLICT (complaint_id, user_id) DO NOTHING`, []interface{}{parent.ID, childIDs}},
+		{`DELETE FROM complaint_votes WHERE complaint_id IN ?`, []interface{}{childIDs}},
+		{`UPDATE complaint_comments SET complaint_id = ? WHERE complaint_id IN ?`, []interface{}{parent.ID, childIDs}},
+		{`UPDATE action_takens SET complaint_id = ? WHERE complaint_id IN ?`, []interface{}{parent.ID, childIDs}},
+		// Anything previously merged into a child now points at the parent
+		{`UPDATE complaints SET merged_into_id = ? WHERE merged_into_id IN ?`, []interface{}{parent.ID, childIDs}},
+	}
+	for _, step := range steps {
+		if err := tx.Exec(step.sql, step.args...).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	reason := fmt.Sprintf("merged into #%d", parent.ID)
+	for i := range children {
+		child := &children[i]
+		if err := transitionStatus(tx, child, StatusMerged, actor, reason); err != nil {
+			return nil, err
+		}
+		err := tx.Model(child).Updates(map[string]interface{}{
+			"merged_into_id": parent.ID, "merged_at": now, "upvotes": 0, "downvotes": 0,
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := recountVotes(tx, parent.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.First(&parent, parent.ID).Error; err != nil {
+		return nil, err
+	}
+	return &parent, nil
+}
+
+func mergeComplaintsHandler(c *gin.Context) {
+	parentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid complaint id"})
+		return
+	}
+	var body struct {
+		ChildIDs []uint `json:"child_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var parent *Complaint
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		parent, err = mergeComplaints(tx, uint(parentID), body.ChildIDs, actorFromContext(c), func(complaint *Complaint) bool {
+			return canManageComplaint(c, complaint)
+		})
+		return err
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "complaint not found"})
+		return
+	case errors.Is(err, errMergeForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errMergeConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"complaint": parent, "merged_ids": body.ChildIDs})
+}
+
+// mergedPointer is what GET returns for a complaint that was merged away.
+func mergedPointer(child Complaint) gin.H {
+	return gin.H{
+		"id":             child.ID,
+		"status":         child.Status,
+		"merged_into_id": *child.MergedIntoID,
+		"merged_at":      child.MergedAt,
+		"location":       fmt.Sprintf("/complaints/%d", *child.MergedIntoID),
+	}
+}
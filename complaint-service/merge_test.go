@@ -0,0 +1,119 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// mergeRow is a complaint row for the merge stubs.
+func mergeRow(id, govID int64, status string) []driver.Value {
+	return []driver.Value{id, govID, status, int64(1)}
+}
+
+func TestMergeComplaints(t *testing.T) {
+	columns := []string{"id", "government_id", "status", "version"}
+	admin := statusActor{ID: 7, Type: "admin"}
+	tests := []struct {
+		name     string
+		parent   []driver.Value
+		childIDs []uint
+		children [][]driver.Value
+		allowed  func(*Complaint) bool
+		wantErr  error
+	}{
+		{
+			name:     "merged",
+			parent:   mergeRow(1, 2, StatusInProgress),
+			childIDs: []uint{3, 4},
+			children: [][]driver.Value{mergeRow(3, 2, StatusPending), mergeRow(4, 2, StatusAcknowledged)},
+		},
+		{
+			name:     "repeated child ids",
+			parent:   mergeRow(1, 2, StatusPending),
+			childIDs: []uint{3, 3},
+			children: [][]driver.Value{mergeRow(3, 2, StatusPending)},
+		},
+		{name: "parent closed", parent: mergeRow(1, 2, StatusClosed), childIDs: []uint{3}, wantErr: errMergeConflict},
+		{name: "parent missing", childIDs: []uint{3}, wantErr: gorm.ErrRecordNotFound},
+		{
+			name:     "missing child",
+			parent:   mergeRow(1, 2, StatusPending),
+			childIDs: []uint{3, 4},
+			children: [][]driver.Value{mergeRow(3, 2, StatusPending)},
+			wantErr:  errMergeConflict,
+		},
+		{
+			name:     "into itself",
+			parent:   mergeRow(1, 2, StatusPending),
+			childIDs: []uint{1},
+			children: [][]driver.Value{mergeRow(1, 2, StatusPending)},
+			wantErr:  errMergeConflict,
+		},
+		{
+			name:     "other government",
+			parent:   mergeRow(1, 2, StatusPending),
+			childIDs: []uint{3},
+			children: [][]driver.Value{mergeRow(3, 5, StatusPending)},
+			wantErr:  errMergeConflict,
+		},
+		{
+			name:     "child already resolved",
+			parent:   mergeRow(1, 2, StatusPending),
+			childIDs: []uint{3},
+			children: [][]driver.Value{mergeRow(3, 2, StatusResolved)},
+			wantErr:  errMergeConflict,
+		},
+		{
+			name:     "child outside jurisdiction",
+			parent:   mergeRow(1, 2, StatusPending),
+			childIDs: []uint{3},
+			children: [][]driver.Value{mergeRow(3, 2, StatusPending)},
+			allowed:  func(c *Complaint) bool { return c.ID == 1 },
+			wantErr:  errMergeForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			if tt.parent != nil {
+				stub.returns(`"complaints"."id" = `, columns, tt.parent)
+			}
+			stub.returns("id IN (", columns, tt.children...)
+			allowed := tt.allowed
+			if allowed == nil {
+				allowed = func(*Complaint) bool { return true }
+			}
+
+			parent, err := mergeComplaints(db, 1, tt.childIDs, admin, allowed)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if stub.ran("INSERT INTO complaint_votes") {
+					t.Error("votes moved despite the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parent.ID != 1 {
+				t.Errorf("parent = #%d", parent.ID)
+			}
+			for _, step := range []string{
+				"INSERT INTO complaint_votes", "DELETE FROM complaint_votes", "UPDATE complaint_comments",
+				"UPDATE action_takens", "SET merged_into_id = ", `"merged_into_id"`, "upvotes = (SELECT COUNT(*)",
+			} {
+				if !stub.ran(step) {
+					t.Errorf("%q not sent", step)
+				}
+			}
+			if !stub.ran(`INSERT INTO "complaint_status_histories"`) {
+				t.Error("children not transitioned to merged")
+			}
+		})
+	}
+}
@@ -0,0 +1,653 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ── Moderation ──────────────────────────────────────────────────────────────
+//
+// Complaints and comments carry a moderation_status:
+//   visible → held    the content filter flagged it on write, or
+//                     MODERATION_REPORT_THRESHOLD (3) citizens reported it
+//   held|visible → visible | hidden   a manager's decision
+// Held and hidden content is left out of public listings and search, and
+// comment text is blanked in threads. Every filter hit and citizen report is
+// a ContentReport; reports on the same item form one queue entry until a
+// manager decides. Decisions (approve | hide | ban_user) are kept in
+// moderation_decisions as the audit trail; ban_user also hides the item and
+// bars its citizen author from posting to that government (UserBan).
+
+const (
+	ModerationVisible = "visible"
+	ModerationHeld    = "held"
+	ModerationHidden  = "hidden"
+
+	DecisionApprove = "approve"
+	DecisionHide    = "hide"
+	DecisionBanUser = "ban_user"
+	DecisionUnban   = "unban"
+)
+
+var (
+	errUnknownTarget = errors.New("target_type must be complaint or comment")
+	errStaffAuthor   = errors.New("staff authors cannot be banned here")
+)
+
+// ContentReport — a citizen report or automatic filter hit awaiting review
+type ContentReport struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	TargetType   string     `gorm:"not null;index:idx_report_target" json:"target_type"` // complaint | comment
+	TargetID     uint       `gorm:"not null;index:idx_report_target" json:"target_id"`
+	ComplaintID  uint       `gorm:"index;not null" json:"complaint_id"`
+	GovernmentID uint       `gorm:"index;not null" json:"government_id"`
+	Source       string     `gorm:"not null" json:"source"` // citizen | filter
+	ReporterID   uint       `json:"reporter_id,omitempty"`
+	Reason       string     `gorm:"not null" json:"reason"`
+	Details      string     `gorm:"type:text" json:"details,omitempty"`
+	ResolvedAt   *time.Time `gorm:"index" json:"resolved_at,omitempty"`
+	DecisionID   *uint      `json:"decision_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ModerationDecision — audit trail of moderator actions
+type ModerationDecision struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	TargetType   string    `gorm:"not null;index:idx_decision_target" json:"target_type"`
+	TargetID     uint      `gorm:"not null;index:idx_decision_target" json:"target_id"`
+	ComplaintID  uint      `gorm:"index" json:"complaint_id,omitempty"`
+	GovernmentID uint      `gorm:"index" json:"government_id,omitempty"`
+	Decision     string    `gorm:"not null" json:"decision"`
+	Reason       string    `gorm:"type:text" json:"reason,omitempty"`
+	FromStatus   string    `json:"from_status,omitempty"`
+	ToStatus     string    `json:"to_status,omitempty"`
+	UserID       *uint     `json:"user_id,omitempty"` // banned / unbanned citizen
+	AdminID      uint      `gorm:"not null" json:"admin_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UserBan — a citizen barred from posting to one government, optionally
+// until ExpiresAt
+type UserBan struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"index;not null" json:"user_id"`
+	GovernmentID uint       `gorm:"index;not null;default:0" json:"government_id"`
+	Reason       string     `gorm:"type:text" json:"reason,omitempty"`
+	AdminID      uint       `gorm:"not null" json:"admin_id"`
+	DecisionID   uint       `json:"decision_id"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	LiftedAt     *time.Time `json:"lifted_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ── Content Filter ──
+
+var (
+	// Digit runs of phone/ID length, allowing spaces, dots and dashes between groups
+	numberRunPattern = regexp.MustCompile(`\+?\d[\d\s.-]{8,18}\d`)
+	wordPattern      = regexp.MustCompile(`[\p{L}']+`)
+
+	defaultBlocklist = []string{
+		"fuck", "fucking", "motherfucker", "shit", "bitch", "bastard", "asshole", "cunt", "dick",
+		"chutiya", "madarchod", "behenchod", "bhenchod", "gandu", "harami", "randi",
+	}
+)
+
+// Verhoeff tables; Aadhaar numbers end in a Verhoeff check digit
+var (
+	verhoeffD = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6}, {3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8}, {5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2}, {7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4}, {9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffP = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2}, {8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0}, {4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5}, {7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+func verhoeffValid(digits string) bool {
+	check := 0
+	for i := 0; i < len(digits); i++ {
+		check = verhoeffD[check][verhoeffP[i%8][digits[len(digits)-1-i]-'0']]
+	}
+	return check == 0
+}
+
+// isIndianMobile accepts 10 digits starting 6-9, optionally prefixed 0 or 91.
+func isIndianMobile(digits string) bool {
+	switch {
+	case len(digits) == 12 && strings.HasPrefix(digits, "91"):
+		digits = digits[2:]
+	case len(digits) == 11 && digits[0] == '0':
+		digits = digits[1:]
+	}
+	return len(digits) == 10 && digits[0] >= '6'
+}
+
+func blocklist() map[string]bool {
+	words := map[string]bool{}
+	for _, w := range defaultBlocklist {
+		words[w] = true
+	}
+	for _, w := range strings.Split(env("MODERATION_BLOCKLIST", ""), ",") {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// screenContent returns why text should be held: profanity, phone_number,
+// aadhaar_number. Empty means the text is fine.
+func screenContent(texts ...string) []string {
+	text := strings.Join(texts, "\n")
+	found := map[string]bool{}
+	for _, run := range numberRunPattern.FindAllString(text, -1) {
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, run)
+		switch {
+		case len(digits) == 12 && digits[0] >= '2' && verhoeffValid(digits):
+			found["aadhaar_number"] = true
+		case isIndianMobile(digits):
+			found["phone_number"] = true
+		}
+	}
+	words := blocklist()
+	for _, w := range wordPattern.FindAllString(strings.ToLower(text), -1) {
+		if words[w] {
+			found["profanity"] = true
+			break
+		}
+	}
+	reasons := []string{}
+	for _, r := range []string{"profanity", "phone_number", "aadhaar_number"} {
+		if found[r] {
+			reasons = append(reasons, r)
+		}
+	}
+	return reasons
+}
+
+// recordFilterHold files the automatic report for content screenContent held.
+func recordFilterHold(tx *gorm.DB, targetType string, targetID uint, complaint *Complaint, reasons []string) error {
+	return tx.Create(&ContentReport{
+		TargetType:   targetType,
+		TargetID:     targetID,
+		ComplaintID:  complaint.ID,
+		GovernmentID: complaint.GovernmentID,
+		Source:       "filter",
+		Reason:       strings.Join(reasons, ","),
+	}).Error
+}
+
+// ── Targets ──
+
+func moderationTable(targetType string) (string, error) {
+	switch targetType {
+	case "complaint":
+		return "complaints", nil
+	case "comment":
+		return "complaint_comments", nil
+	}
+	return "", errUnknownTarget
+}
+
+// moderationTarget is a complaint or comment with what moderation needs.
+type moderationTarget struct {
+	Complaint Complaint
+	Comment   *ComplaintComment
+}
+
+func (t *moderationTarget) status() string {
+	if t.Comment != nil {
+		return t.Comment.ModerationStatus
+	}
+	return t.Complaint.ModerationStatus
+}
+
+// author is the citizen who wrote the target; 0 for staff comments.
+func (t *moderationTarget) author() uint {
+	if t.Comment != nil {
+		if t.Comment.AdminID != nil {
+			return 0
+		}
+		return t.Comment.UserID
+	}
+	return t.Complaint.UserID
+}
+
+func loadModerationTarget(tx *gorm.DB, targetType string, targetID uint) (*moderationTarget, error) {
+	target := &moderationTarget{}
+	complaintID := targetID
+	switch targetType {
+	case "comment":
+		var comment ComplaintComment
+		tx.Limit(1).Find(&comment, targetID)
+		if comment.ID == 0 || comment.DeletedAt != nil {
+			return nil, errCommentNotFound
+		}
+		target.Comment, complaintID = &comment, comment.ComplaintID
+	case "complaint":
+	default:
+		return nil, errUnknownTarget
+	}
+	tx.Limit(1).Find(&target.Complaint, complaintID)
+	if target.Complaint.ID == 0 {
+		return nil, errors.New("complaint not found")
+	}
+	return target, nil
+}
+
+func setModerationStatus(tx *gorm.DB, targetType string, targetID uint, status string) error {
+	table, err := moderationTable(targetType)
+	if err != nil {
+		return err
+	}
+	return tx.Table(table).Where("id = ?", targetID).UpdateColumn("moderation_status", status).Error
+}
+
+// ── Bans ──
+
+// activeBan finds the citizen's ban in govID; 0 matches a ban anywhere.
+func activeBan(userID, govID uint) *UserBan {
+	var ban UserBan
+	query := db.Where("user_id = ? AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now())
+	if govID != 0 {
+		query = query.Where("government_id = ?", govID)
+	}
+	query.Order("expires_at DESC NULLS FIRST").Limit(1).Find(&ban)
+	if ban.ID == 0 {
+		return nil
+	}
+	return &ban
+}
+
+// rejectBanned answers 403 when the calling citizen is banned in govID;
+// staff pass through.
+func rejectBanned(c *gin.Context, govID uint) bool {
+	userID := getUserID(c)
+	if userID == 0 {
+		return false
+	}
+	ban := activeBan(userID, govID)
+	if ban == nil {
+		return false
+	}
+	msg := "your account is suspended from posting"
+	if ban.ExpiresAt != nil {
+		msg += " until " + ban.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": msg})
+	return true
+}
+
+// notBanned stops banned citizens from posting to the government of the
+// complaint in :id. Routes without one (uploads) are not tied to a
+// government yet, so a ban anywhere applies; filing a complaint and the
+// legacy comment route check in the handler once the government is known.
+func notBanned() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var govID uint
+		if id := c.Param("id"); id != "" {
+			db.Model(&Complaint{}).Select("government_id").Where("id = ?", id).Limit(1).Scan(&govID)
+		}
+		if rejectBanned(c, govID) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// migrateBanGovernments scopes bans issued before bans carried a
+// government to the government of the decision that issued them.
+func migrateBanGovernments() {
+	err := db.Exec(`UPDATE user_bans b SET government_id = d.government_id
+		FROM moderation_decisions d
+		WHERE d.id = b.decision_id AND b.government_id = 0`).Error
+	if err != nil {
+		log.Printf("[complaint-service] Ban government migration failed: %v", err)
+	}
+}
+
+// ── Citizen Reports ──
+
+var reportReasons = map[string]bool{"spam": true, "abuse": true, "personal_info": true, "misleading": true, "other": true}
+
+func fileReport(c *gin.Context, targetType string, targetID uint) {
+	var body struct {
+		Reason  string `json:"reason" binding:"required"`
+		Details string `json:"details"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !reportReasons[body.Reason] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason must be spam, abuse, personal_info, misleading or other"})
+		return
+	}
+	target, err := loadModerationTarget(db, targetType, targetID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := ContentReport{
+		TargetType:   targetType,
+		TargetID:     targetID,
+		ComplaintID:  target.Complaint.ID,
+		GovernmentID: target.Complaint.GovernmentID,
+		Source:       "citizen",
+		ReporterID:   getUserID(c),
+		Reason:       body.Reason,
+		Details:      body.Details,
+	}
+	held := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&report)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		if target.status() != ModerationVisible {
+			return nil
+		}
+		var open int64
+		tx.Model(&ContentReport{}).
+			Where("target_type = ? AND target_id = ? AND source = 'citizen' AND resolved_at IS NULL", targetType, targetID).
+			Count(&open)
+		if open < int64(envInt("MODERATION_REPORT_THRESHOLD", 3)) {
+			return nil
+		}
+		held = true
+		return setModerationStatus(tx, targetType, targetID, ModerationHeld)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if report.ID == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "you have already reported this"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"report": report, "held": held})
+}
+
+// POST /complaints/:id/report
+func reportComplaintHandler(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	fileReport(c, "complaint", uint(id))
+}
+
+// POST /complaints/:id/comments/:comment_id/report
+func reportCommentHandler(c *gin.Context) {
+	var comment ComplaintComment
+	db.Where("id = ? AND complaint_id = ?", c.Param("comment_id"), c.Param("id")).Limit(1).Find(&comment)
+	if comment.ID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": errCommentNotFound.Error()})
+		return
+	}
+	fileReport(c, "comment", comment.ID)
+}
+
+// ── Moderation Queue ──
+
+// canSeeModerated: a held or hidden complaint stays readable by the citizen
+// who filed it and by staff who manage it.
+func canSeeModerated(c *gin.Context, complaint *Complaint) bool {
+	if userID := getUserID(c); userID != 0 && complaint.UserID == userID {
+		return true
+	}
+	return canManageComplaint(c, complaint)
+}
+
+// moderationGovScope is the government a moderator works on; 0 = all
+// (super admins, optionally narrowed with ?government_id=).
+func moderationGovScope(c *gin.Context) uint {
+	if getAdminRole(c) != "super_admin" {
+		return getGovID(c)
+	}
+	id, _ := strconv.Atoi(c.Query("government_id"))
+	return uint(id)
+}
+
+// GET /complaints/moderation/queue?target_type=&limit=&offset=
+//
+// One entry per reported item, filter holds first, then by report count.
+func moderationQueueHandler(c *gin.Context) {
+	limit := pageLimit(c)
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+	targetType := c.Query("target_type")
+	if targetType != "" {
+		if _, err := moderationTable(targetType); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	type queueEntry struct {
+		TargetType       string    `json:"target_type"`
+		TargetID         uint      `json:"target_id"`
+		ComplaintID      uint      `json:"complaint_id"`
+		GovernmentID     uint      `json:"government_id"`
+		Reports          int       `json:"reports"`
+		Filtered         bool      `json:"filtered"`
+		Reasons          string    `json:"reasons"`
+		FirstReportedAt  time.Time `json:"first_reported_at"`
+		Content          string    `json:"content"`
+		AuthorUserID     uint      `json:"author_user_id,omitempty"`
+		AuthorAdminID    *uint     `json:"author_admin_id,omitempty"`
+		ModerationStatus string    `json:"moderation_status"`
+		Total            int64     `json:"-"`
+	}
+	entries := []queueEntry{}
+	err := db.Raw(`
+		SELECT r.*, COALESCE(cm.content, c.description) AS content,
+			COALESCE(cm.user_id, c.user_id) AS author_user_id, cm.admin_id AS author_admin_id,
+			COALESCE(cm.moderation_status, c.moderation_status) AS moderation_status,
+			COUNT(*) OVER () AS total
+		FROM (
+			SELECT target_type, target_id, complaint_id, government_id,
+				COUNT(*) AS reports, BOOL_OR(source = 'filter') AS filtered,
+				STRING_AGG(DISTINCT reason, ',') AS reasons, MIN(created_at) AS first_reported_at
+			FROM content_reports
+			WHERE resolved_at IS NULL
+				AND (@gov = 0 OR government_id = @gov)
+				AND (@type = '' OR target_type = @type)
+			GROUP BY target_type, target_id, complaint_id, government_id
+		) r
+		JOIN complaints c ON c.id = r.complaint_id
+		LEFT JOIN complaint_comments cm ON r.target_type = 'comment' AND cm.id = r.target_id
+		ORDER BY r.filtered DESC, r.reports DESC, r.first_reported_at
+		LIMIT @limit OFFSET @offset`,
+		map[string]interface{}{"gov": moderationGovScope(c), "type": targetType, "limit": limit, "offset": offset},
+	).Scan(&entries).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var total int64
+	if len(entries) > 0 {
+		total = entries[0].Total
+	}
+	c.JSON(http.StatusOK, gin.H{"queue": entries, "total": total, "limit": limit, "offset": offset})
+}
+
+// POST /complaints/moderation/decisions
+func moderationDecisionHandler(c *gin.Context) {
+	var body struct {
+		TargetType string `json:"target_type" binding:"required"`
+		TargetID   uint   `json:"target_id" binding:"required"`
+		Decision   string `json:"decision" binding:"required,oneof=approve hide ban_user"`
+		Reason     string `json:"reason"`
+		BanDays    int    `json:"ban_days"` // ban_user only; 0 = until lifted
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	target, err := loadModerationTarget(db, body.TargetType, body.TargetID)
+	if errors.Is(err, errUnknownTarget) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !canManageGovernment(c, target.Complaint.GovernmentID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "content is outside your jurisdiction"})
+		return
+	}
+
+	decision := ModerationDecision{
+		TargetType:   body.TargetType,
+		TargetID:     body.TargetID,
+		ComplaintID:  target.Complaint.ID,
+		GovernmentID: target.Complaint.GovernmentID,
+		Decision:     body.Decision,
+		Reason:       body.Reason,
+		FromStatus:   target.status(),
+		ToStatus:     ModerationHidden,
+		AdminID:      getAdminID(c),
+	}
+	if body.Decision == DecisionApprove {
+		decision.ToStatus = ModerationVisible
+	}
+	if body.Decision == DecisionBanUser {
+		author := target.author()
+		if author == 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": errStaffAuthor.Error()})
+			return
+		}
+		decision.UserID = &author
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := setModerationStatus(tx, body.TargetType, body.TargetID, decision.ToStatus); err != nil {
+			return err
+		}
+		if err := tx.Create(&decision).Error; err != nil {
+			return err
+		}
+		if decision.UserID != nil {
+			ban := UserBan{
+				UserID:       *decision.UserID,
+				GovernmentID: decision.GovernmentID,
+				Reason:       body.Reason,
+				AdminID:      decision.AdminID,
+				DecisionID:   decision.ID,
+			}
+			if body.BanDays > 0 {
+				until := time.Now().AddDate(0, 0, body.BanDays)
+				ban.ExpiresAt = &until
+			}
+			if err := tx.Create(&ban).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&ContentReport{}).
+			Where("target_type = ? AND target_id = ? AND resolved_at IS NULL", body.TargetType, body.TargetID).
+			Updates(map[string]interface{}{"resolved_at": time.Now(), "decision_id": decision.ID}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, decision)
+}
+
+// GET /complaints/moderation/decisions?target_type=&target_id=&user_id=
+func listModerationDecisionsHandler(c *gin.Context) {
+	query := db.Order("created_at DESC, id DESC").Limit(pageLimit(c))
+	if gov := moderationGovScope(c); gov != 0 {
+		query = query.Where("government_id = ?", gov)
+	}
+	if targetType := c.Query("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID := c.Query("target_id"); targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	decisions := []ModerationDecision{}
+	query.Find(&decisions)
+	c.JSON(http.StatusOK, decisions)
+}
+
+// GET /complaints/moderation/bans?active=true&government_id=
+func listBansHandler(c *gin.Context) {
+	query := db.Order("created_at DESC").Limit(pageLimit(c))
+	if gov := moderationGovScope(c); gov != 0 {
+		query = query.Where("government_id = ?", gov)
+	}
+	if c.Query("active") == "true" {
+		query = query.Where("lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+	}
+	bans := []UserBan{}
+	query.Find(&bans)
+	c.JSON(http.StatusOK, bans)
+}
+
+// DELETE /complaints/moderation/bans/:id — lift a ban early
+func liftBanHandler(c *gin.Context) {
+	var ban UserBan
+	if err := db.First(&ban, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ban not found"})
+		return
+	}
+	if ban.LiftedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "ban already lifted"})
+		return
+	}
+	if !canManageGovernment(c, ban.GovernmentID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "ban was issued outside your jurisdiction"})
+		return
+	}
+	var original ModerationDecision
+	db.Limit(1).Find(&original, ban.DecisionID)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ban).Update("lifted_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&ModerationDecision{
+			TargetType:   original.TargetType,
+			TargetID:     original.TargetID,
+			ComplaintID:  original.ComplaintID,
+			GovernmentID: original.GovernmentID,
+			Decision:     DecisionUnban,
+			Reason:       fmt.Sprintf("ban #%d lifted", ban.ID),
+			UserID:       &ban.UserID,
+			AdminID:      getAdminID(c),
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ban)
+}
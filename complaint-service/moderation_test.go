@@ -0,0 +1,179 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVerhoeffValid(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   bool
+	}{
+		{"2363", true},
+		{"2364", false},
+		{"234567890124", true},
+		{"234567890125", false},
+		{"491873652014", true},
+		{"491873652041", false}, // transposed digits
+		{"0", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if got := verhoeffValid(tt.digits); got != tt.want {
+			t.Errorf("verhoeffValid(%q) = %v, want %v", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestScreenContent(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		want  []string
+	}{
+		{name: "clean", texts: []string{"Garbage not collected for a week near the bus stop"}, want: []string{}},
+		{name: "mobile with space", texts: []string{"call me on 98765 43210"}, want: []string{"phone_number"}},
+		{name: "mobile with country code", texts: []string{"WhatsApp +91-98765-43210"}, want: []string{"phone_number"}},
+		{name: "mobile with trunk prefix", texts: []string{"ph 09876543210"}, want: []string{"phone_number"}},
+		{name: "aadhaar", texts: []string{"my aadhaar is 2345 6789 0124"}, want: []string{"aadhaar_number"}},
+		{name: "aadhaar with bad check digit", texts: []string{"ref 2345 6789 0125"}, want: []string{}},
+		{name: "aadhaar cannot start with 1", texts: []string{"ref 1234 5678 9010"}, want: []string{}},
+		{name: "dates, amounts and pincodes", texts: []string{"since 2024-05-02, Rs 1,50,000 spent, pin 560001"}, want: []string{}},
+		{name: "short numbers", texts: []string{"ward 12, pole 4471"}, want: []string{}},
+		{name: "profanity", texts: []string{"This road is SHIT"}, want: []string{"profanity"}},
+		{name: "hindi profanity", texts: []string{"contractor is a chutiya"}, want: []string{"profanity"}},
+		{name: "whole words only", texts: []string{"Dickens Road, Scunthorpe Lane"}, want: []string{}},
+		{
+			name:  "split across fields",
+			texts: []string{"Broken pipe, what the fuck", "contact 98765 43210 or 2345-6789-0124"},
+			want:  []string{"profanity", "phone_number", "aadhaar_number"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := screenContent(tt.texts...); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("screenContent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScreenContentBlocklistEnv(t *testing.T) {
+	t.Setenv("MODERATION_BLOCKLIST", " Scammer , ,crook")
+	if got := screenContent("the Scammer contractor"); len(got) != 1 || got[0] != "profanity" {
+		t.Errorf("screenContent() = %v, want [profanity]", got)
+	}
+	if got := screenContent("a plain complaint"); len(got) != 0 {
+		t.Errorf("screenContent() = %v, want none", got)
+	}
+}
+
+func TestCanSeeModerated(t *testing.T) {
+	dept := func(id uint) *uint { return &id }
+	complaint := &Complaint{UserID: 5, GovernmentID: 2, DepartmentID: dept(7)}
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		want   bool
+	}{
+		{name: "anonymous", want: false},
+		{name: "author", claims: map[string]interface{}{"user_id": float64(5)}, want: true},
+		{name: "other citizen", claims: map[string]interface{}{"user_id": float64(6)}, want: false},
+		{name: "super admin", claims: map[string]interface{}{"admin_id": float64(1), "admin_role": "super_admin"}, want: true},
+		{name: "manager", claims: map[string]interface{}{"admin_id": float64(1), "admin_role": "manager", "government_id": float64(2)}, want: true},
+		{name: "other government", claims: map[string]interface{}{"admin_id": float64(1), "admin_role": "manager", "government_id": float64(3)}, want: false},
+		{
+			name:   "own department",
+			claims: map[string]interface{}{"admin_id": float64(1), "admin_role": "dept_manager", "government_id": float64(2), "department_id": float64(7)},
+			want:   true,
+		},
+		{
+			name:   "other department",
+			claims: map[string]interface{}{"admin_id": float64(1), "admin_role": "dept_manager", "government_id": float64(2), "department_id": float64(8)},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			for k, v := range tt.claims {
+				c.Set(k, v)
+			}
+			if got := canSeeModerated(c, complaint); got != tt.want {
+				t.Errorf("canSeeModerated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotBanned(t *testing.T) {
+	citizen := map[string]interface{}{"user_id": float64(5)}
+	tests := []struct {
+		name       string
+		claims     map[string]interface{}
+		complaint  string // :id, empty on routes not tied to a complaint
+		banned     bool
+		wantCode   int
+		wantScoped bool // ban lookup narrowed to the complaint's government
+	}{
+		{name: "not banned", claims: citizen, complaint: "1", wantCode: http.StatusOK, wantScoped: true},
+		{name: "banned in the complaint's government", claims: citizen, complaint: "1", banned: true, wantCode: http.StatusForbidden, wantScoped: true},
+		{name: "upload, banned anywhere", claims: citizen, banned: true, wantCode: http.StatusForbidden},
+		{name: "staff", claims: superAdminClaims, complaint: "1", banned: true, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.returns(`FROM "complaints"`, []string{"government_id"}, []driver.Value{int64(2)})
+			if tt.banned {
+				stub.returns(`FROM "user_bans"`, []string{"id", "user_id", "government_id"}, []driver.Value{int64(9), int64(5), int64(2)})
+			}
+			c, w := newTestContext(http.MethodPut, "/complaints/"+tt.complaint, `{}`, tt.claims)
+			if tt.complaint != "" {
+				c.Params = gin.Params{{Key: "id", Value: tt.complaint}}
+			}
+
+			notBanned()(c)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if scoped := stub.ran("government_id = "); scoped != tt.wantScoped {
+				t.Errorf("ban lookup scoped to a government = %v, want %v", scoped, tt.wantScoped)
+			}
+		})
+	}
+}
+
+func TestListBansHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     map[string]interface{}
+		query      string
+		wantScoped bool
+	}{
+		{name: "manager sees own government", claims: map[string]interface{}{"admin_id": float64(3), "admin_role": "manager", "government_id": float64(2)}, wantScoped: true},
+		{name: "manager cannot widen", claims: map[string]interface{}{"admin_id": float64(3), "admin_role": "manager", "government_id": float64(2)}, query: "?government_id=0", wantScoped: true},
+		{name: "super admin sees all", claims: superAdminClaims},
+		{name: "super admin narrows", claims: superAdminClaims, query: "?government_id=4", wantScoped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			c, w := newTestContext(http.MethodGet, "/complaints/moderation/bans"+tt.query, "", tt.claims)
+
+			listBansHandler(c)
+			if w.Code != http.StatusOK {
+				t.Fatalf("code = %d: %s", w.Code, w.Body)
+			}
+			if scoped := stub.ran("government_id = "); scoped != tt.wantScoped {
+				t.Errorf("scoped to a government = %v, want %v", scoped, tt.wantScoped)
+			}
+		})
+	}
+}
@@ -0,0 +1,624 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ── Open311 GeoReport v2 ────────────────────────────────────────────────────
+//
+// Base URL: /complaints/open311/v2   (jurisdiction_id = government ID)
+//   GET  services.{json|xml}           categories as services
+//   GET  services/{code}.{json|xml}    service definition (no extra attributes)
+//   GET  requests.{json|xml}           complaints, filtered per the spec
+//   POST requests.{json|xml}           file a complaint (api_key required)
+//   GET  requests/{id}.{json|xml}      one complaint
+//
+// service_code is the slugged category ("Street Light" → "street-light").
+// Services are the categories in use in the jurisdiction plus any listed in
+// OPEN311_CATEGORIES. Lifecycle statuses collapse onto open/closed.
+//
+// A POSTed media_url is kept as an external link (external_media_url), not
+// as one of our photos: it never went through the upload pipeline. Likely
+// duplicates are refused with 409 unless the client sends force=true.
+
+// Open311APIKey — credential for a third-party client. Only the SHA-256 of
+// the key is stored; the plaintext is shown once on creation.
+type Open311APIKey struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Name         string     `gorm:"not null" json:"name"`
+	GovernmentID *uint      `gorm:"index" json:"government_id,omitempty"` // nil: any jurisdiction
+	KeyHash      string     `gorm:"uniqueIndex;not null" json:"-"`
+	Prefix       string     `json:"prefix"`
+	CreatedBy    uint       `json:"created_by"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type open311Error struct {
+	XMLName     xml.Name `json:"-" xml:"error"`
+	Code        int      `json:"code" xml:"code"`
+	Description string   `json:"description" xml:"description"`
+}
+
+type open311Service struct {
+	XMLName     xml.Name `json:"-" xml:"service"`
+	ServiceCode string   `json:"service_code" xml:"service_code"`
+	ServiceName string   `json:"service_name" xml:"service_name"`
+	Description string   `json:"description" xml:"description"`
+	Metadata    bool     `json:"metadata" xml:"metadata"`
+	Type        string   `json:"type" xml:"type"`
+	Keywords    string   `json:"keywords" xml:"keywords"`
+	Group       string   `json:"group" xml:"group"`
+}
+
+type open311Request struct {
+	XMLName           xml.Name `json:"-" xml:"request"`
+	ServiceRequestID  string   `json:"service_request_id" xml:"service_request_id"`
+	Status            string   `json:"status" xml:"status"`
+	StatusNotes       string   `json:"status_notes,omitempty" xml:"status_notes,omitempty"`
+	ServiceName       string   `json:"service_name" xml:"service_name"`
+	ServiceCode       string   `json:"service_code" xml:"service_code"`
+	Description       string   `json:"description" xml:"description"`
+	AgencyResponsible string   `json:"agency_responsible,omitempty" xml:"agency_responsible,omitempty"`
+	RequestedDatetime string   `json:"requested_datetime" xml:"requested_datetime"`
+	UpdatedDatetime   string   `json:"updated_datetime" xml:"updated_datetime"`
+	ExpectedDatetime  string   `json:"expected_datetime,omitempty" xml:"expected_datetime,omitempty"`
+	Address           string   `json:"address,omitempty" xml:"address,omitempty"`
+	Lat               float64  `json:"lat" xml:"lat"`
+	Long              float64  `json:"long" xml:"long"`
+	MediaURL          string   `json:"media_url,omitempty" xml:"media_url,omitempty"`
+}
+
+type open311ServiceDefinition struct {
+	XMLName     xml.Name   `json:"-" xml:"service_definition"`
+	ServiceCode string     `json:"service_code" xml:"service_code"`
+	Attributes  []struct{} `json:"attributes" xml:"attributes>attribute"`
+}
+
+type open311Created struct {
+	XMLName          xml.Name `json:"-" xml:"request"`
+	ServiceRequestID string   `json:"service_request_id" xml:"service_request_id"`
+	ServiceNotice    string   `json:"service_notice,omitempty" xml:"service_notice,omitempty"`
+}
+
+// XML documents wrap their items in a plural root element
+type open311ErrorList struct {
+	XMLName xml.Name `xml:"errors"`
+	Items   []open311Error
+}
+type open311ServiceList struct {
+	XMLName xml.Name `xml:"services"`
+	Items   []open311Service
+}
+type open311RequestList struct {
+	XMLName xml.Name `xml:"service_requests"`
+	Items   []open311Request
+}
+type open311CreatedList struct {
+	XMLName xml.Name `xml:"service_requests"`
+	Items   []open311Created
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+func serviceCode(category string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(category), "-"), "-")
+}
+
+// open311State maps the complaint lifecycle onto Open311's open/closed.
+func open311State(status string) string {
+	switch status {
+	case StatusResolved, StatusClosed, StatusRejected, StatusMerged:
+		return "closed"
+	}
+	return "open"
+}
+
+// splitFormat turns "requests.json" into ("requests", "json").
+func splitFormat(resource string) (string, string, bool) {
+	dot := strings.LastIndex(resource, ".")
+	if dot < 0 {
+		return "", "", false
+	}
+	name, format := resource[:dot], resource[dot+1:]
+	return name, format, format == "json" || format == "xml"
+}
+
+func open311Respond(c *gin.Context, format string, status int, items interface{}) {
+	if format != "xml" {
+		c.JSON(status, items)
+		return
+	}
+	var doc interface{}
+	switch v := items.(type) {
+	case []open311Error:
+		doc = open311ErrorList{Items: v}
+	case []open311Service:
+		doc = open311ServiceList{Items: v}
+	case []open311Request:
+		doc = open311RequestList{Items: v}
+	case []open311Created:
+		doc = open311CreatedList{Items: v}
+	case open311ServiceDefinition:
+		doc = v
+	}
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Data(status, "text/xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
+
+func open311Fail(c *gin.Context, format string, status int, description string) {
+	open311Respond(c, format, status, []open311Error{{Code: status, Description: description}})
+}
+
+// open311Services lists the categories of a jurisdiction (0 = all) as services.
+func open311Services(govID uint) ([]open311Service, error) {
+	var categories []string
+	complaints := db.Model(&Complaint{}).Distinct("category").Where("category <> ''")
+	policies := db.Model(&SLAPolicy{}).Distinct("category").Where("category <> ''")
+	if govID != 0 {
+		complaints = complaints.Where("government_id = ?", govID)
+		policies = policies.Where("government_id = ?", govID)
+	}
+	if err := complaints.Pluck("category", &categories).Error; err != nil {
+		return nil, err
+	}
+	var policyCategories []string
+	if err := policies.Pluck("category", &policyCategories).Error; err != nil {
+		return nil, err
+	}
+	categories = append(categories, policyCategories...)
+	categories = append(categories, splitIDs(env("OPEN311_CATEGORIES", ""))...)
+
+	byCode := map[string]open311Service{}
+	for _, category := range categories {
+		code := serviceCode(category)
+		if code == "" {
+			continue
+		}
+		if _, seen := byCode[code]; !seen {
+			byCode[code] = open311Service{
+				ServiceCode: code,
+				ServiceName: category,
+				Description: category + " complaints",
+				Type:        "realtime",
+				Keywords:    strings.ToLower(category),
+				Group:       "complaints",
+			}
+		}
+	}
+	services := make([]open311Service, 0, len(byCode))
+	for _, s := range byCode {
+		services = append(services, s)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].ServiceCode < services[j].ServiceCode })
+	return services, nil
+}
+
+func jurisdictionParam(c *gin.Context) uint {
+	id, _ := strconv.ParseUint(c.Query("jurisdiction_id"), 10, 64)
+	return uint(id)
+}
+
+func toOpen311Request(complaint *Complaint, notes string) open311Request {
+	req := open311Request{
+		ServiceRequestID:  strconv.FormatUint(uint64(complaint.ID), 10),
+		Status:            open311State(complaint.Status),
+		StatusNotes:       notes,
+		ServiceName:       complaint.Category,
+		ServiceCode:       serviceCode(complaint.Category),
+		Description:       complaint.Description,
+		RequestedDatetime: complaint.CreatedAt.Format(time.RFC3339),
+		UpdatedDatetime:   complaint.UpdatedAt.Format(time.RFC3339),
+		Address:           complaint.ManualLocation,
+		Lat:               complaint.Latitude,
+		Long:              complaint.Longitude,
+	}
+	if complaint.DepartmentID != nil {
+		req.AgencyResponsible = "department " + strconv.FormatUint(uint64(*complaint.DepartmentID), 10)
+	}
+	if complaint.ResolveDueAt != nil {
+		req.ExpectedDatetime = complaint.ResolveDueAt.Format(time.RFC3339)
+	}
+	var urls []string
+	if json.Unmarshal([]byte(complaint.MultimediaURLs), &urls) == nil && len(urls) > 0 {
+		req.MediaURL = urls[0]
+	} else {
+		req.MediaURL = complaint.ExternalMediaURL
+	}
+	return req
+}
+
+// open311Requests converts complaints, using the latest history reason (or the
+// lifecycle status) as status_notes.
+func open311Requests(complaints []Complaint) []open311Request {
+	ids := make([]uint, len(complaints))
+	for i := range complaints {
+		ids[i] = complaints[i].ID
+	}
+	notes := map[uint]string{}
+	if len(ids) > 0 {
+		var latest []ComplaintStatusHistory
+		db.Raw(`SELECT DISTINCT ON (complaint_id) * FROM complaint_status_histories
+			WHERE complaint_id IN ? ORDER BY complaint_id, created_at DESC, id DESC`, ids).Scan(&latest)
+		for _, h := range latest {
+			notes[h.ComplaintID] = h.Reason
+		}
+	}
+	out := make([]open311Request, len(complaints))
+	for i := range complaints {
+		note := notes[complaints[i].ID]
+		if note == "" {
+			note = strings.ReplaceAll(complaints[i].Status, "_", " ")
+		}
+		out[i] = toOpen311Request(&complaints[i], note)
+	}
+	return out
+}
+
+// migrateOpen311Media moves media_url links that earlier versions stored in
+// multimedia_urls over to external_media_url.
+func migrateOpen311Media() {
+	err := db.Exec(`UPDATE complaints
+		SET external_media_url = multimedia_urls::jsonb->>0, multimedia_urls = ''
+		WHERE open311_key_id IS NOT NULL AND COALESCE(external_media_url, '') = ''
+			AND multimedia_urls LIKE '["http%' AND multimedia_urls NOT LIKE '%/complaints/media/%'`).Error
+	if err != nil {
+		log.Printf("[complaint-service] Open311 media_url migration failed: %v", err)
+	}
+}
+
+// ── API Keys ────────────────────────────────────────────────────────────────
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+var errInvalidAPIKey = errors.New("invalid api_key")
+
+func lookupAPIKey(key string) (*Open311APIKey, error) {
+	if key == "" {
+		return nil, errInvalidAPIKey
+	}
+	var apiKey Open311APIKey
+	err := db.Where("key_hash = ? AND revoked_at IS NULL", hashAPIKey(key)).Limit(1).Find(&apiKey).Error
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.ID == 0 {
+		return nil, errInvalidAPIKey
+	}
+	db.Model(&apiKey).Update("last_used_at", time.Now())
+	return &apiKey, nil
+}
+
+// POST /complaints/open311/keys (Manager+) — returns the plaintext key once
+func createAPIKeyHandler(c *gin.Context) {
+	var body struct {
+		Name         string `json:"name" binding:"required"`
+		GovernmentID *uint  `json:"government_id"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if getAdminRole(c) != "super_admin" {
+		govID := getGovID(c)
+		body.GovernmentID = &govID
+	}
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	key := "o311_" + hex.EncodeToString(raw)
+	apiKey := Open311APIKey{
+		Name:         body.Name,
+		GovernmentID: body.GovernmentID,
+		KeyHash:      hashAPIKey(key),
+		Prefix:       key[:12],
+		CreatedBy:    getAdminID(c),
+	}
+	if err := db.Create(&apiKey).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"key": key, "api_key": apiKey})
+}
+
+func listAPIKeysHandler(c *gin.Context) {
+	query := db.Order("created_at DESC")
+	if getAdminRole(c) != "super_admin" {
+		query = query.Where("government_id = ?", getGovID(c))
+	}
+	var keys []Open311APIKey
+	query.Find(&keys)
+	c.JSON(http.StatusOK, keys)
+}
+
+func revokeAPIKeyHandler(c *gin.Context) {
+	var apiKey Open311APIKey
+	if err := db.First(&apiKey, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+	if getAdminRole(c) != "super_admin" && (apiKey.GovernmentID == nil || *apiKey.GovernmentID != getGovID(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "api key belongs to another government"})
+		return
+	}
+	now := time.Now()
+	db.Model(&apiKey).Update("revoked_at", now)
+	apiKey.RevokedAt = &now
+	c.JSON(http.StatusOK, apiKey)
+}
+
+// ── Open311 Handlers ────────────────────────────────────────────────────────
+
+// GET /complaints/open311/v2/:resource — services.{fmt} | requests.{fmt}
+func open311ListHandler(c *gin.Context) {
+	name, format, ok := splitFormat(c.Param("resource"))
+	if !ok {
+		open311Fail(c, "json", http.StatusNotFound, "unknown resource")
+		return
+	}
+	switch name {
+	case "services":
+		services, err := open311Services(jurisdictionParam(c))
+		if err != nil {
+			open311Fail(c, format, http.StatusInternalServerError, err.Error())
+			return
+		}
+		open311Respond(c, format, http.StatusOK, services)
+	case "requests":
+		open311SearchRequests(c, format)
+	default:
+		open311Fail(c, format, http.StatusNotFound, "unknown resource")
+	}
+}
+
+// open311SearchRequests implements GET requests: service_request_id (comma
+// list, overrides everything else), service_code, start_date, end_date,
+// status=open|closed. Defaults to the last 90 days, at most 1000 results.
+func open311SearchRequests(c *gin.Context, format string) {
+	query := db.Model(&Complaint{}).Where("moderation_status = ?", ModerationVisible)
+	if values := splitIDs(c.Query("service_request_id")); len(values) > 0 {
+		ids := make([]uint64, len(values))
+		for i, v := range values {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				open311Fail(c, format, http.StatusBadRequest, "service_request_id must be a comma list of numeric IDs")
+				return
+			}
+			ids[i] = id
+		}
+		query = query.Where("id IN ?", ids)
+	} else {
+		if govID := jurisdictionParam(c); govID != 0 {
+			query = query.Where("government_id = ?", govID)
+		}
+		if codes := splitIDs(c.Query("service_code")); len(codes) > 0 {
+			query = query.Where("TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(category), '[^a-z0-9]+', '-', 'g')) IN ?", codes)
+		}
+		end := time.Now()
+		if v := c.Query("end_date"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				open311Fail(c, format, http.StatusBadRequest, "end_date must be w3c (RFC3339) format")
+				return
+			}
+			end = t
+		}
+		start := end.AddDate(0, 0, -90)
+		if v := c.Query("start_date"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				open311Fail(c, format, http.StatusBadRequest, "start_date must be w3c (RFC3339) format")
+				return
+			}
+			start = t
+		}
+		query = query.Where("created_at BETWEEN ? AND ?", start, end)
+		switch c.Query("status") {
+		case "":
+		case "open":
+			query = query.Where("status IN ?", openStatuses)
+		case "closed":
+			query = query.Where("status NOT IN ?", openStatuses)
+		default:
+			open311Fail(c, format, http.StatusBadRequest, "status must be open or closed")
+			return
+		}
+	}
+	var complaints []Complaint
+	if err := query.Order("created_at DESC").Limit(1000).Find(&complaints).Error; err != nil {
+		open311Fail(c, format, http.StatusInternalServerError, err.Error())
+		return
+	}
+	open311Respond(c, format, http.StatusOK, open311Requests(complaints))
+}
+
+// GET /complaints/open311/v2/requests/:id — id carries the format suffix
+func open311GetRequestHandler(c *gin.Context) {
+	id, format, ok := splitFormat(c.Param("id"))
+	if !ok {
+		open311Fail(c, "json", http.StatusNotFound, "unknown resource")
+		return
+	}
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		open311Fail(c, format, http.StatusBadRequest, "service_request_id must be numeric")
+		return
+	}
+	var complaint Complaint
+	err := db.Where("id = ? AND moderation_status = ?", id, ModerationVisible).Limit(1).Find(&complaint).Error
+	if err != nil || complaint.ID == 0 {
+		open311Fail(c, format, http.StatusNotFound, "service_request_id not found")
+		return
+	}
+	open311Respond(c, format, http.StatusOK, open311Requests([]Complaint{complaint}))
+}
+
+// GET /complaints/open311/v2/services/:code — no extra attributes are defined
+func open311ServiceDefinitionHandler(c *gin.Context) {
+	code, format, ok := splitFormat(c.Param("code"))
+	if !ok {
+		open311Fail(c, "json", http.StatusNotFound, "unknown resource")
+		return
+	}
+	services, err := open311Services(jurisdictionParam(c))
+	if err != nil {
+		open311Fail(c, format, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, s := range services {
+		if s.ServiceCode == code {
+			open311Respond(c, format, http.StatusOK, open311ServiceDefinition{ServiceCode: code, Attributes: []struct{}{}})
+			return
+		}
+	}
+	open311Fail(c, format, http.StatusNotFound, "service_code not found")
+}
+
+// POST /complaints/open311/v2/requests.{fmt} — form-encoded per the spec
+func open311CreateRequestHandler(c *gin.Context) {
+	name, format, ok := splitFormat(c.Param("resource"))
+	if !ok || name != "requests" {
+		open311Fail(c, "json", http.StatusNotFound, "unknown resource")
+		return
+	}
+	key := c.PostForm("api_key")
+	if key == "" {
+		key = c.GetHeader("X-API-Key")
+	}
+	apiKey, err := lookupAPIKey(key)
+	if errors.Is(err, errInvalidAPIKey) {
+		open311Fail(c, format, http.StatusForbidden, "api_key is missing or invalid")
+		return
+	}
+	if err != nil {
+		open311Fail(c, format, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	lat, errLat := strconv.ParseFloat(c.PostForm("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.PostForm("long"), 64)
+	if errLat != nil || errLng != nil {
+		open311Fail(c, format, http.StatusBadRequest, "lat and long are required")
+		return
+	}
+	description := strings.TrimSpace(c.PostForm("description"))
+	if description == "" {
+		open311Fail(c, format, http.StatusBadRequest, "description is required")
+		return
+	}
+
+	complaint := Complaint{
+		Description:    description,
+		Latitude:       lat,
+		Longitude:      lng,
+		ManualLocation: c.PostForm("address_string"),
+		Open311KeyID:   &apiKey.ID,
+	}
+	if id, _ := strconv.ParseUint(c.PostForm("jurisdiction_id"), 10, 64); id != 0 {
+		complaint.GovernmentID = uint(id)
+	}
+	match, err := resolveJurisdiction(db, lat, lng)
+	switch {
+	case errors.Is(err, errOutsideJurisdiction):
+		open311Fail(c, format, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		open311Fail(c, format, http.StatusInternalServerError, err.Error())
+		return
+	case match != nil:
+		complaint.GovernmentID = match.GovernmentID
+		complaint.WardID, complaint.Ward = match.WardID, match.Ward
+	}
+	if complaint.GovernmentID == 0 {
+		open311Fail(c, format, http.StatusBadRequest, "jurisdiction_id is required")
+		return
+	}
+	if apiKey.GovernmentID != nil && *apiKey.GovernmentID != complaint.GovernmentID {
+		open311Fail(c, format, http.StatusForbidden, "api_key is not valid for this jurisdiction")
+		return
+	}
+
+	services, err := open311Services(complaint.GovernmentID)
+	if err != nil {
+		open311Fail(c, format, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, s := range services {
+		if s.ServiceCode == c.PostForm("service_code") {
+			complaint.Category = s.ServiceName
+		}
+	}
+	if complaint.Category == "" {
+		open311Fail(c, format, http.StatusBadRequest, "service_code not found")
+		return
+	}
+	if mediaURL := strings.TrimSpace(c.PostForm("media_url")); mediaURL != "" {
+		u, err := url.Parse(mediaURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			open311Fail(c, format, http.StatusBadRequest, "media_url must be an http(s) URL")
+			return
+		}
+		complaint.ExternalMediaURL = mediaURL
+	}
+
+	if c.PostForm("force") != "true" {
+		candidates, err := findDuplicateCandidates(duplicateQuery{
+			Category:    complaint.Category,
+			Description: complaint.Description,
+			Latitude:    complaint.Latitude,
+			Longitude:   complaint.Longitude,
+		})
+		if err != nil {
+			open311Fail(c, format, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if len(candidates) > 0 {
+			ids := make([]string, len(candidates))
+			for i, d := range candidates {
+				ids[i] = strconv.FormatUint(uint64(d.ID), 10)
+			}
+			open311Fail(c, format, http.StatusConflict, fmt.Sprintf(
+				"possible duplicate of service_request_id %s; resubmit with force=true", strings.Join(ids, ",")))
+			return
+		}
+	}
+
+	verifyPhotoEvidence(&complaint, nil, time.Now())
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return insertComplaint(tx, &complaint, systemActor)
+	})
+	if err != nil {
+		open311Fail(c, format, http.StatusInternalServerError, err.Error())
+		return
+	}
+	open311Respond(c, format, http.StatusCreated, []open311Created{{
+		ServiceRequestID: strconv.FormatUint(uint64(complaint.ID), 10),
+		ServiceNotice:    "Complaint registered with the local government",
+	}})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServiceCode(t *testing.T) {
+	for category, want := range map[string]string{
+		"Roads":               "roads",
+		"Water Supply":        "water-supply",
+		"  Garbage / Waste ":  "garbage-waste",
+		"Street-lights (LED)": "street-lights-led",
+		"":                    "",
+	} {
+		if got := serviceCode(category); got != want {
+			t.Errorf("serviceCode(%q) = %q, want %q", category, got, want)
+		}
+	}
+}
+
+func TestOpen311State(t *testing.T) {
+	for status, want := range map[string]string{
+		StatusPending: "open", StatusInProgress: "open", StatusPendingVerification: "open", StatusReopened: "open",
+		StatusResolved: "closed", StatusClosed: "closed", StatusRejected: "closed", StatusMerged: "closed",
+	} {
+		if got := open311State(status); got != want {
+			t.Errorf("open311State(%s) = %s, want %s", status, got, want)
+		}
+	}
+}
+
+func TestSplitFormat(t *testing.T) {
+	tests := []struct {
+		resource, name, format string
+		ok                     bool
+	}{
+		{"requests.json", "requests", "json", true},
+		{"services.xml", "services", "xml", true},
+		{"12.json", "12", "json", true},
+		{"water.supply.json", "water.supply", "json", true},
+		{"requests.csv", "requests", "csv", false},
+		{"requests", "", "", false},
+	}
+	for _, tt := range tests {
+		name, format, ok := splitFormat(tt.resource)
+		if name != tt.name || format != tt.format || ok != tt.ok {
+			t.Errorf("splitFormat(%q) = %q, %q, %v", tt.resource, name, format, ok)
+		}
+	}
+}
+
+func TestOpen311SearchRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantCode  int
+		wantByIDs bool
+	}{
+		{name: "by id", query: "?service_request_id=4", wantCode: http.StatusOK, wantByIDs: true},
+		{name: "id list with spaces", query: "?service_request_id=4,+5+,,6", wantCode: http.StatusOK, wantByIDs: true},
+		{name: "ids override other filters", query: "?service_request_id=4&status=bogus", wantCode: http.StatusOK, wantByIDs: true},
+		{name: "non-numeric id", query: "?service_request_id=4,abc", wantCode: http.StatusBadRequest},
+		{name: "negative id", query: "?service_request_id=-4", wantCode: http.StatusBadRequest},
+		{name: "blank id list searches", query: "?service_request_id=+,+", wantCode: http.StatusOK},
+		{name: "bad status", query: "?status=bogus", wantCode: http.StatusBadRequest},
+		{name: "bad date", query: "?start_date=2026-10-01", wantCode: http.StatusBadRequest},
+		{name: "open in a window", query: "?status=open&start_date=2026-09-01T00:00:00Z&end_date=2026-10-01T00:00:00Z", wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			c, w := newTestContext(http.MethodGet, "/complaints/open311/v2/requests.json"+tt.query, "", nil)
+
+			open311SearchRequests(c, "json")
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				var errs []open311Error
+				if json.Unmarshal(w.Body.Bytes(), &errs) != nil || len(errs) != 1 || errs[0].Code != tt.wantCode {
+					t.Errorf("error body = %s", w.Body)
+				}
+				return
+			}
+			if byIDs := stub.ran("id IN ("); byIDs != tt.wantByIDs {
+				t.Errorf("looked up by id = %v, want %v", byIDs, tt.wantByIDs)
+			}
+			if windowed := stub.ran("created_at BETWEEN"); windowed == tt.wantByIDs {
+				t.Errorf("date window applied = %v, want %v", windowed, !tt.wantByIDs)
+			}
+		})
+	}
+}
+
+func TestOpen311GetRequestHandler(t *testing.T) {
+	tests := []struct {
+		id       string
+		stored   bool
+		wantCode int
+		wantXML  bool
+	}{
+		{id: "4.json", stored: true, wantCode: http.StatusOK},
+		{id: "4.xml", stored: true, wantCode: http.StatusOK, wantXML: true},
+		{id: "4.json", wantCode: http.StatusNotFound},
+		{id: "four.json", wantCode: http.StatusBadRequest},
+		{id: "4", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			stub := newStubDB(t)
+			if tt.stored {
+				stub.returns(`FROM "complaints"`, []string{"id", "category", "status"}, []driver.Value{int64(4), "Water Supply", StatusResolved})
+			}
+			c, w := newTestContext(http.MethodGet, "/complaints/open311/v2/requests/"+tt.id, "", nil)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			open311GetRequestHandler(c)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			body := w.Body.String()
+			if isXML := strings.HasPrefix(body, "<?xml"); isXML != tt.wantXML {
+				t.Errorf("xml = %v: %s", isXML, body)
+			}
+			for _, want := range []string{"water-supply", "closed"} {
+				if !strings.Contains(body, want) {
+					t.Errorf("body lacks %q: %s", want, body)
+				}
+			}
+		})
+	}
+}
+
+func TestLookupAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		stored  bool
+		wantErr error
+	}{
+		{name: "valid", key: "o311_secret", stored: true},
+		{name: "unknown or revoked", key: "o311_secret", wantErr: errInvalidAPIKey},
+		{name: "missing", key: "", stored: true, wantErr: errInvalidAPIKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			if tt.stored {
+				stub.returns(`FROM "open311_api_keys"`, []string{"id", "government_id"}, []driver.Value{int64(1), int64(2)})
+			}
+			_, err := lookupAPIKey(tt.key)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if used := stub.ran(`"last_used_at"`); used != (err == nil) {
+				t.Errorf("last_used_at stamped = %v", used)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ── Transactional Outbox ────────────────────────────────────────────────────
+//
+// Complaint mutations write their events into outbox_events inside the same
+// DB transaction. A relay goroutine publishes pending rows to RabbitMQ with
+// publisher confirms and retries with backoff, so an event is sent if and
+// only if its transaction committed (at-least-once).
+//
+//   complaint_events (topic) ← complaint.created | complaint.status_changed |
+//                               complaint.action_added | complaint.reassigned |
+//                               complaint.official_response
+//   complaint_analysis (queue, default exchange) ← AI analysis jobs
+
+const (
+	complaintEventsExchange = "complaint_events"
+	complaintAnalysisQueue  = "complaint_analysis"
+
+	EventComplaintCreated       = "complaint.created"
+	EventComplaintStatusChanged = "complaint.status_changed"
+	EventComplaintActionAdded   = "complaint.action_added"
+	EventComplaintReassigned    = "complaint.reassigned"
+
+	EventComplaintOfficialResponse = "complaint.official_response"
+)
+
+type OutboxEvent struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	EventType   string          `gorm:"not null" json:"event_type"`
+	ComplaintID uint            `gorm:"index" json:"complaint_id"`
+	Exchange    string          `gorm:"not null;default:''" json:"exchange"`
+	RoutingKey  string          `gorm:"not null" json:"routing_key"`
+	Payload     json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+	Attempts    int             `gorm:"not null;default:0" json:"attempts"`
+	LastError   string          `gorm:"type:text" json:"last_error,omitempty"`
+	AvailableAt time.Time       `gorm:"index;not null" json:"available_at"`
+	PublishedAt *time.Time      `gorm:"index" json:"published_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// complaintEvent is the common payload for complaint_events messages.
+func complaintEvent(eventType string, complaint *Complaint, extra map[string]interface{}) map[string]interface{} {
+	payload := map[string]interface{}{
+		"event":         eventType,
+		"complaint_id":  complaint.ID,
+		"government_id": complaint.GovernmentID,
+		"department_id": complaint.DepartmentID,
+		"category":      complaint.Category,
+		"status":        complaint.Status,
+		"version":       complaint.Version,
+		"reopen_count":  complaint.ReopenCount,
+		"occurred_at":   time.Now().UTC(),
+	}
+	for k, v := range extra {
+		payload[k] = v
+	}
+	return payload
+}
+
+func enqueueOutbox(tx *gorm.DB, eventType string, complaintID uint, exchange, routingKey string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&OutboxEvent{
+		EventType:   eventType,
+		ComplaintID: complaintID,
+		Exchange:    exchange,
+		RoutingKey:  routingKey,
+		Payload:     body,
+		AvailableAt: time.Now(),
+	}).Error
+}
+
+// enqueueComplaintEvent records a lifecycle event on the complaint_events exchange.
+func enqueueComplaintEvent(tx *gorm.DB, eventType string, complaint *Complaint, extra map[string]interface{}) error {
+	return enqueueOutbox(tx, eventType, complaint.ID, complaintEventsExchange, eventType,
+		complaintEvent(eventType, complaint, extra))
+}
+
+// enqueueAnalysisJob records the ai-worker job for a complaint.
+func enqueueAnalysisJob(tx *gorm.DB, complaint *Complaint) error {
+	return enqueueOutbox(tx, "complaint.analysis_requested", complaint.ID, "", complaintAnalysisQueue,
+		map[string]interface{}{
+			"complaint_id":  complaint.ID,
+			"description":   complaint.Description,
+			"category":      complaint.Category,
+			"latitude":      complaint.Latitude,
+			"longitude":     complaint.Longitude,
+			"government_id": complaint.GovernmentID,
+		})
+}
+
+// ── Outbox Relay ────────────────────────────────────────────────────────────
+
+type outboxRelay struct {
+	ch *amqp.Channel
+}
+
+func (r *outboxRelay) channel() (*amqp.Channel, error) {
+	if r.ch != nil && !r.ch.IsClosed() {
+		return r.ch, nil
+	}
+	ch, err := amqpConn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	if err := ch.ExchangeDeclare(complaintEventsExchange, "topic", true, false, false, false, nil); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	if _, err := ch.QueueDeclare(complaintAnalysisQueue, true, false, false, false, nil); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	r.ch = ch
+	return ch, nil
+}
+
+func (r *outboxRelay) publish(event *OutboxEvent) error {
+	ch, err := r.channel()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, event.Exchange, event.RoutingKey, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    fmt.Sprintf("outbox-%d", event.ID),
+		Type:         event.EventType,
+		Timestamp:    event.CreatedAt,
+		Body:         event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return fmt.Errorf("broker nacked message")
+	}
+	return nil
+}
+
+// outboxBackoff: 2s, 4s, 8s … capped at 5 minutes.
+func outboxBackoff(attempts int) time.Duration {
+	if attempts > 8 {
+		return 5 * time.Minute
+	}
+	return time.Duration(1<<attempts) * time.Second
+}
+
+// claimOutboxBatch leases up to 50 due events to this relay in a short
+// transaction (SKIP LOCKED, so replicas can relay concurrently). Publishing
+// happens afterwards, outside any transaction; an event whose lease runs out
+// before it is published is simply picked up again.
+//
+// Events of one complaint go out in order: an event is only claimed together
+// with every earlier unpublished event of its complaint, so nothing overtakes
+// an event that is backed off or leased to another relay.
+func claimOutboxBatch() ([]OutboxEvent, error) {
+	var claimed []OutboxEvent
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var events []OutboxEvent
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND available_at <= ?", time.Now()).
+			Order("id").Limit(50).Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		inBatch := map[uint]bool{}
+		complaintIDs := []uint{}
+		for _, e := range events {
+			inBatch[e.ID] = true
+			complaintIDs = append(complaintIDs, e.ComplaintID)
+		}
+		var pending []OutboxEvent
+		if err := tx.Select("id, complaint_id").
+			Where("published_at IS NULL AND complaint_id IN ? AND complaint_id <> 0 AND id <= ?", complaintIDs, events[len(events)-1].ID).
+			Order("id").Find(&pending).Error; err != nil {
+			return err
+		}
+		// blockedFrom[c] is the first pending event of complaint c held elsewhere
+		blockedFrom := map[uint]uint{}
+		for _, p := range pending {
+			if _, blocked := blockedFrom[p.ComplaintID]; !blocked && !inBatch[p.ID] {
+				blockedFrom[p.ComplaintID] = p.ID
+			}
+		}
+		ids := []uint{}
+		for _, e := range events {
+			if first, blocked := blockedFrom[e.ComplaintID]; blocked && e.ComplaintID != 0 && first < e.ID {
+				continue
+			}
+			claimed = append(claimed, e)
+			ids = append(ids, e.ID)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		lease := envDuration("OUTBOX_LEASE", 10*time.Minute)
+		return tx.Model(&OutboxEvent{}).Where("id IN ?", ids).Update("available_at", time.Now().Add(lease)).Error
+	})
+	return claimed, err
+}
+
+// relayBatch publishes one claimed batch in order. After a failure the rest
+// of the batch is released for the next tick, where the failed event's
+// backoff keeps later events of its complaint waiting.
+func (r *outboxRelay) relayBatch() (int, error) {
+	events, err := claimOutboxBatch()
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	for i := range events {
+		event := &events[i]
+		if err := r.publish(event); err != nil {
+			event.Attempts++
+			db.Model(event).Updates(map[string]interface{}{
+				"attempts":     event.Attempts,
+				"last_error":   err.Error(),
+				"available_at": time.Now().Add(outboxBackoff(event.Attempts)),
+			})
+			rest := []uint{0}
+			for _, e := range events[i+1:] {
+				rest = append(rest, e.ID)
+			}
+			db.Model(&OutboxEvent{}).Where("id IN ?", rest).Update("available_at", time.Now())
+			// Likely a broken channel: reopen it and retry the rest next tick
+			if r.ch != nil {
+				r.ch.Close()
+				r.ch = nil
+			}
+			return published, nil
+		}
+		if err := db.Model(event).Update("published_at", time.Now()).Error; err != nil {
+			// Sent but not marked: the lease expires and it is sent again,
+			// which at-least-once consumers already tolerate
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+func runOutboxRelay() {
+	interval, err := time.ParseDuration(env("OUTBOX_POLL_INTERVAL", "1s"))
+	if err != nil || interval <= 0 {
+		interval = time.Second
+	}
+	retention, err := time.ParseDuration(env("OUTBOX_RETENTION", "168h"))
+	if err != nil || retention <= 0 {
+		retention = 7 * 24 * time.Hour
+	}
+
+	relay := &outboxRelay{}
+	lastPrune := time.Now()
+	for {
+		n, err := relay.relayBatch()
+		if err != nil {
+			log.Printf("[complaint-service] Outbox relay error: %v", err)
+		}
+		if time.Since(lastPrune) > time.Hour {
+			db.Where("published_at < ?", time.Now().Add(-retention)).Delete(&OutboxEvent{})
+			lastPrune = time.Now()
+		}
+		// Drain quickly while there is a backlog
+		if n == 50 {
+			continue
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestClaimOutboxBatch(t *testing.T) {
+	event := func(id, complaintID int64) []driver.Value { return []driver.Value{id, complaintID} }
+	tests := []struct {
+		name    string
+		due     [][]driver.Value // id, complaint_id of due events
+		pending [][]driver.Value // every unpublished event of those complaints
+		want    []uint
+	}{
+		{name: "nothing due"},
+		{
+			name:    "whole batch",
+			due:     [][]driver.Value{event(10, 1), event(11, 2), event(12, 1)},
+			pending: [][]driver.Value{event(10, 1), event(11, 2), event(12, 1)},
+			want:    []uint{10, 11, 12},
+		},
+		{
+			name:    "earlier event backed off",
+			due:     [][]driver.Value{event(10, 1), event(11, 2), event(12, 1)},
+			pending: [][]driver.Value{event(5, 1), event(10, 1), event(11, 2), event(12, 1)},
+			want:    []uint{11},
+		},
+		{
+			name:    "earlier event leased elsewhere",
+			due:     [][]driver.Value{event(12, 1), event(13, 3)},
+			pending: [][]driver.Value{event(11, 1), event(12, 1), event(13, 3)},
+			want:    []uint{13},
+		},
+		{
+			name:    "events without a complaint never wait",
+			due:     [][]driver.Value{event(10, 0), event(11, 0)},
+			pending: nil,
+			want:    []uint{10, 11},
+		},
+		{
+			name:    "everything blocked",
+			due:     [][]driver.Value{event(12, 1)},
+			pending: [][]driver.Value{event(11, 1), event(12, 1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.returns("SKIP LOCKED", []string{"id", "complaint_id"}, tt.due...)
+			stub.returns("SELECT id, complaint_id", []string{"id", "complaint_id"}, tt.pending...)
+
+			claimed, err := claimOutboxBatch()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := []uint{}
+			for _, e := range claimed {
+				got = append(got, e.ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(append([]uint{}, tt.want...)) {
+				t.Errorf("claimed = %v, want %v", got, tt.want)
+			}
+			if leased := stub.ran(`UPDATE "outbox_events" SET "available_at"`); leased != (len(tt.want) > 0) {
+				t.Errorf("lease written = %v", leased)
+			}
+			if len(tt.due) > 0 && !stub.ran("ORDER BY id LIMIT") {
+				t.Error("due events are not claimed oldest first with SKIP LOCKED")
+			}
+		})
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{8, 256 * time.Second},
+		{9, 5 * time.Minute},
+		{40, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := outboxBackoff(tt.attempts); got != tt.want {
+			t.Errorf("outboxBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestComplaintEvent(t *testing.T) {
+	complaint := &Complaint{ID: 1, GovernmentID: 2, Category: "Roads", Status: StatusResolved, Version: 4}
+	payload := complaintEvent(EventComplaintStatusChanged, complaint, map[string]interface{}{"from_status": StatusInProgress, "status": "overridden"})
+	if payload["event"] != EventComplaintStatusChanged || payload["complaint_id"] != uint(1) || payload["version"] != 4 {
+		t.Errorf("payload = %v", payload)
+	}
+	if payload["from_status"] != StatusInProgress || payload["status"] != "overridden" {
+		t.Errorf("extra fields not merged over the defaults: %v", payload)
+	}
+}
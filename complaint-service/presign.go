@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+// ── Presigned Uploads + Private Media ───────────────────────────────────────
+//
+// Clients upload straight to MinIO:
+//   1. POST /complaints/uploads/presign {kind, content_type, size}
+//      → PUT URL whose signature covers Content-Type and Content-Length, for
+//        a staging object under uploads/
+//   2. PUT the bytes to that URL (same headers)
+//   3. POST /complaints/uploads/:id/finalize → the staged object is sniffed,
+//      bounded and re-encoded by the normal pipeline (media.go), renditions
+//      are stored and the staging object is deleted
+// Staging objects expire after a day via a bucket lifecycle rule.
+//
+// The bucket stays private. Stored media URLs point at GET /complaints/media/
+// which redirects to a presigned GET URL valid for MEDIA_URL_TTL.
+
+var minioPresigner *minio.Client
+
+const stagingRuleID = "expire-staged-uploads"
+
+// PendingUpload — a presigned PUT awaiting finalize
+type PendingUpload struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	ObjectName  string     `gorm:"uniqueIndex;not null" json:"object_name"`
+	Kind        string     `gorm:"not null" json:"kind"` // complaints | actions
+	OwnerType   string     `gorm:"not null" json:"owner_type"`
+	OwnerID     uint       `gorm:"not null" json:"owner_id"`
+	ContentType string     `gorm:"not null" json:"content_type"`
+	Size        int64      `gorm:"not null" json:"size"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	FinalizedAt *time.Time `json:"finalized_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// connectMinIOPresigner builds a client for the endpoint browsers can reach
+// (signatures cover the host) and installs the staging lifecycle rule.
+func connectMinIOPresigner() {
+	endpoint := env("MINIO_PUBLIC_ENDPOINT", env("MINIO_ENDPOINT", "localhost:9000"))
+	var err error
+	minioPresigner, err = minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(env("MINIO_ACCESS_KEY", "civic_minio"), env("MINIO_SECRET_KEY", "minio_secret_2026"), ""),
+		Secure: env("MINIO_PUBLIC_SECURE", "false") == "true",
+		Region: env("MINIO_REGION", "us-east-1"), // avoids a bucket-location lookup against the public host
+	})
+	if err != nil {
+		log.Fatalf("[complaint-service] MinIO presigner setup failed: %v", err)
+	}
+
+	// SetBucketLifecycle replaces the whole configuration, so keep any
+	// rules someone else has put on the bucket
+	ctx := context.Background()
+	rules, err := minioClient.GetBucketLifecycle(ctx, mediaBucket())
+	if err != nil {
+		if minio.ToErrorResponse(err).Code != "NoSuchLifecycleConfiguration" {
+			log.Printf("[complaint-service] Upload lifecycle rule warning: %v", err)
+			return
+		}
+		rules = lifecycle.NewConfiguration()
+	}
+	kept := rules.Rules[:0]
+	for _, rule := range rules.Rules {
+		if rule.ID != stagingRuleID {
+			kept = append(kept, rule)
+		}
+	}
+	rules.Rules = append(kept, lifecycle.Rule{
+		ID:         stagingRuleID,
+		Status:     "Enabled",
+		RuleFilter: lifecycle.Filter{Prefix: "uploads/"},
+		Expiration: lifecycle.Expiration{Days: 1},
+	})
+	if err := minioClient.SetBucketLifecycle(ctx, mediaBucket(), rules); err != nil {
+		log.Printf("[complaint-service] Upload lifecycle rule warning: %v", err)
+	}
+}
+
+// migrateLegacyMediaURLs rewrites stored http://MINIO_ENDPOINT/bucket/...
+// links, which stopped working when the bucket went private, to mediaURL.
+func migrateLegacyMediaURLs() {
+	legacy := fmt.Sprintf("http://%s/%s/", env("MINIO_ENDPOINT", "localhost:9000"), mediaBucket())
+	columns := map[string]string{"complaints": "multimedia_urls", "action_takens": "action_multimedia_urls"}
+	for table, column := range columns {
+		for _, kind := range []string{"complaints/", "actions/"} {
+			err := db.Exec(fmt.Sprintf("UPDATE %s SET %s = REPLACE(%[2]s, @old, @new) WHERE strpos(%[2]s, @old) > 0", table, column),
+				map[string]interface{}{"old": legacy + kind, "new": mediaURL(kind)}).Error
+			if err != nil {
+				log.Printf("[complaint-service] Legacy media URL migration (%s) failed: %v", table, err)
+			}
+		}
+	}
+}
+
+// uploadOwner identifies the caller for a PendingUpload.
+func uploadOwner(c *gin.Context) (string, uint) {
+	actor := actorFromContext(c)
+	return actor.Type, actor.ID
+}
+
+// POST /complaints/uploads/presign
+func presignUploadHandler(c *gin.Context) {
+	var body struct {
+		Kind        string `json:"kind" binding:"required,oneof=complaints actions"`
+		ContentType string `json:"content_type" binding:"required"`
+		Size        int64  `json:"size" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Kind == "actions" && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only staff can upload action images"})
+		return
+	}
+	if !allowedImageTypes[body.ContentType] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": errUnsupportedImage.Error()})
+		return
+	}
+	if body.Size > int64(envInt("UPLOAD_MAX_BYTES", 10<<20)) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errImageTooLarge.Error()})
+		return
+	}
+
+	prefix, err := newMediaPrefix("uploads/" + body.Kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ttl := envDuration("UPLOAD_URL_TTL", 10*time.Minute)
+	headers := http.Header{}
+	headers.Set("Content-Type", body.ContentType)
+	headers.Set("Content-Length", strconv.FormatInt(body.Size, 10))
+	putURL, err := minioPresigner.PresignHeader(c.Request.Context(), http.MethodPut, mediaBucket(),
+		prefix+"/upload", ttl, url.Values{}, headers)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ownerType, ownerID := uploadOwner(c)
+	upload := PendingUpload{
+		ObjectName:  prefix + "/upload",
+		Kind:        body.Kind,
+		OwnerType:   ownerType,
+		OwnerID:     ownerID,
+		ContentType: body.ContentType,
+		Size:        body.Size,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := db.Create(&upload).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"upload":  upload,
+		"method":  http.MethodPut,
+		"url":     putURL.String(),
+		"headers": gin.H{"Content-Type": body.ContentType, "Content-Length": strconv.FormatInt(body.Size, 10)},
+	})
+}
+
+// POST /complaints/uploads/:id/finalize
+func finalizeUploadHandler(c *gin.Context) {
+	var upload PendingUpload
+	if err := db.First(&upload, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+		return
+	}
+	if ownerType, ownerID := uploadOwner(c); upload.OwnerType != ownerType || upload.OwnerID != ownerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "upload belongs to another user"})
+		return
+	}
+	// A PUT started just before the URL expired still gets to finish
+	if time.Now().After(upload.ExpiresAt.Add(envDuration("UPLOAD_FINALIZE_GRACE", 10*time.Minute))) {
+		c.JSON(http.StatusGone, gin.H{"error": "upload has expired; request a new one"})
+		return
+	}
+
+	// Claim the upload before touching the object, so of two concurrent
+	// finalizes only one stores it
+	claim := db.Model(&PendingUpload{}).
+		Where("id = ? AND finalized_at IS NULL", upload.ID).
+		Update("finalized_at", time.Now())
+	if claim.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": claim.Error.Error()})
+		return
+	}
+	if claim.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "upload already finalized"})
+		return
+	}
+	finalized := false
+	defer func() {
+		// Anything short of a stored image leaves the upload retryable
+		if !finalized {
+			db.Model(&PendingUpload{}).Where("id = ?", upload.ID).Update("finalized_at", nil)
+		}
+	}()
+
+	ctx := c.Request.Context()
+	info, err := minioClient.StatObject(ctx, mediaBucket(), upload.ObjectName, minio.StatObjectOptions{})
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "object has not been uploaded yet"})
+		return
+	}
+	if info.Size != upload.Size {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("uploaded %d bytes, expected %d", info.Size, upload.Size)})
+		return
+	}
+	obj, err := minioClient.GetObject(ctx, mediaBucket(), upload.ObjectName, minio.GetObjectOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(obj, upload.Size))
+	obj.Close()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The declared type was only a hint; the bytes decide
+	decoded, err := decodeUpload(data)
+	if err != nil {
+		minioClient.RemoveObject(ctx, mediaBucket(), upload.ObjectName, minio.RemoveObjectOptions{})
+		c.JSON(uploadErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	stored, err := storeImage(ctx, upload.Kind, decoded)
+	if err == nil {
+		err = recordMediaObject(c, upload.Kind, stored, decoded)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	finalized = true
+	minioClient.RemoveObject(ctx, mediaBucket(), upload.ObjectName, minio.RemoveObjectOptions{})
+	c.JSON(http.StatusOK, stored)
+}
+
+// GET /complaints/media/*object — short-lived redirect into the private bucket
+func mediaRedirectHandler(c *gin.Context) {
+	objectName := strings.TrimPrefix(c.Param("object"), "/")
+	if strings.Contains(objectName, "..") ||
+		!(strings.HasPrefix(objectName, "complaints/") || strings.HasPrefix(objectName, "actions/")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+		return
+	}
+	ttl := envDuration("MEDIA_URL_TTL", 15*time.Minute)
+	signed, err := minioPresigner.PresignedGetObject(c.Request.Context(), mediaBucket(), objectName, ttl, url.Values{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// Let browsers reuse the redirect for most of the signature's lifetime
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(ttl.Seconds())*3/4))
+	c.Redirect(http.StatusFound, signed.String())
+}
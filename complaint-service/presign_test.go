@@ -0,0 +1,89 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPresignUploadHandlerRejects(t *testing.T) {
+	citizen := map[string]interface{}{"user_id": float64(5)}
+	tests := []struct {
+		name     string
+		claims   map[string]interface{}
+		body     string
+		wantCode int
+	}{
+		{name: "unknown kind", claims: citizen, body: `{"kind":"avatars","content_type":"image/jpeg","size":100}`, wantCode: http.StatusBadRequest},
+		{name: "no size", claims: citizen, body: `{"kind":"complaints","content_type":"image/jpeg"}`, wantCode: http.StatusBadRequest},
+		{name: "citizen action image", claims: citizen, body: `{"kind":"actions","content_type":"image/jpeg","size":100}`, wantCode: http.StatusForbidden},
+		{name: "not an image", claims: citizen, body: `{"kind":"complaints","content_type":"application/pdf","size":100}`, wantCode: http.StatusUnsupportedMediaType},
+		{name: "too large", claims: superAdminClaims, body: `{"kind":"actions","content_type":"image/png","size":20971520}`, wantCode: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newStubDB(t)
+			c, w := newTestContext(http.MethodPost, "/complaints/uploads/presign", tt.body, tt.claims)
+
+			presignUploadHandler(c)
+			if w.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+}
+
+func TestFinalizeUploadHandlerRejects(t *testing.T) {
+	citizen := map[string]interface{}{"user_id": float64(5)}
+	tests := []struct {
+		name      string
+		claims    map[string]interface{}
+		exists    bool
+		expiresIn time.Duration
+		claimed   int64 // rows the finalized_at IS NULL claim affects
+		wantCode  int
+	}{
+		{name: "not found", claims: citizen, wantCode: http.StatusNotFound},
+		{name: "someone else's upload", claims: map[string]interface{}{"user_id": float64(6)}, exists: true, expiresIn: time.Minute, wantCode: http.StatusForbidden},
+		{name: "staff with the same id", claims: map[string]interface{}{"admin_id": float64(5), "admin_role": "manager"}, exists: true, expiresIn: time.Minute, wantCode: http.StatusForbidden},
+		{name: "expired past the grace period", claims: citizen, exists: true, expiresIn: -time.Hour, claimed: 1, wantCode: http.StatusGone},
+		{name: "finalized already or concurrently", claims: citizen, exists: true, expiresIn: time.Minute, claimed: 0, wantCode: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.execRows = tt.claimed
+			if tt.exists {
+				stub.returns(`FROM "pending_uploads"`,
+					[]string{"id", "object_name", "kind", "owner_type", "owner_id", "content_type", "size", "expires_at"},
+					[]driver.Value{int64(3), "uploads/complaints/2026/10/abc/upload", "complaints", "citizen", int64(5), "image/jpeg", int64(100), time.Now().Add(tt.expiresIn)})
+			}
+			c, w := newTestContext(http.MethodPost, "/complaints/uploads/3/finalize", "", tt.claims)
+			c.Params = gin.Params{{Key: "id", Value: "3"}}
+
+			finalizeUploadHandler(c)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			claimTried := tt.wantCode == http.StatusConflict
+			if ran := stub.ran("finalized_at IS NULL"); ran != claimTried {
+				t.Errorf("guarded claim sent = %v, want %v", ran, claimTried)
+			}
+		})
+	}
+}
+
+func TestMediaRedirectHandlerRejects(t *testing.T) {
+	for _, object := range []string{"/uploads/complaints/2026/10/abc/upload", "/complaints/../uploads/x", "/", "/secrets.txt"} {
+		c, w := newTestContext(http.MethodGet, "/complaints/media"+object, "", nil)
+		c.Params = gin.Params{{Key: "object", Value: object}}
+
+		mediaRedirectHandler(c)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: code = %d, want 404", object, w.Code)
+		}
+	}
+}
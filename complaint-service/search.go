@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ── Full-Text Search ────────────────────────────────────────────────────────
+//
+// complaints.search_vector and complaint_comments.search_vector are stored
+// generated tsvector columns (see migrateSearchVectors). Each indexes its text
+// twice: with the stemming 'english' config and with 'simple', which keeps
+// transliterated Hindi/Tamil words ("sadak", "kuppai") intact instead of
+// mangling them with English stemming rules. Complaint fields are weighted
+// description (A) > category (B) > manual_location (C).
+
+// searchConfigs maps ?lang= onto the tsquery built from q.
+var searchConfigs = map[string]string{
+	"":        "websearch_to_tsquery('english', @q) || websearch_to_tsquery('simple', @q)",
+	"all":     "websearch_to_tsquery('english', @q) || websearch_to_tsquery('simple', @q)",
+	"english": "websearch_to_tsquery('english', @q)",
+	"simple":  "websearch_to_tsquery('simple', @q)",
+}
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=12, MaxFragments=2"
+
+// migrateSearchVectors adds the generated columns and their GIN indexes.
+func migrateSearchVectors() {
+	db.Exec(`ALTER TABLE complaints ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', COALESCE(description, '')), 'A') ||
+		setweight(to_tsvector('english', COALESCE(category, '')), 'B') ||
+		setweight(to_tsvector('english', COALESCE(manual_location, '')), 'C') ||
+		setweight(to_tsvector('simple', COALESCE(description, '')), 'A') ||
+		setweight(to_tsvector('simple', COALESCE(category, '')), 'B') ||
+		setweight(to_tsvector('simple', COALESCE(manual_location, '')), 'C')
+	) STORED`)
+	db.Exec(`ALTER TABLE complaint_comments ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		to_tsvector('english', COALESCE(content, '')) || to_tsvector('simple', COALESCE(content, ''))
+	) STORED`)
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_complaint_search ON complaints USING GIN (search_vector)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_comment_search ON complaint_comments USING GIN (search_vector)")
+}
+
+// SearchResult — a complaint ranked against the query, with highlighted
+// fragments of the description and of the best matching comment.
+type SearchResult struct {
+	Complaint
+	Rank             float64 `json:"rank"`
+	Highlight        string  `json:"highlight,omitempty"`
+	CommentHighlight string  `json:"comment_highlight,omitempty"`
+	Total            int64   `json:"-"`
+}
+
+// GET /complaints/search?q=pothole+"main road"&lang=all|english|simple
+// Accepts the list filters too; results are ordered by rank, paged with
+// limit/offset. Comment matches count at half weight.
+func searchComplaintsHandler(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	tsquery, ok := searchConfigs[c.Query("lang")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lang must be all, english or simple"})
+		return
+	}
+	filtered, err := applyFieldFilters(c, db.Model(&Complaint{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := pageLimit(c)
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	var results []SearchResult
+	err = db.Raw(`
+		WITH query AS (SELECT `+tsquery+` AS tsq),
+		comment_hits AS (
+			SELECT cc.complaint_id,
+				MAX(ts_rank_cd(cc.search_vector, query.tsq)) AS rank,
+				(ARRAY_AGG(ts_headline('english', cc.content, query.tsq, @opts)
+					ORDER BY ts_rank_cd(cc.search_vector, query.tsq) DESC))[1] AS snippet
+			FROM complaint_comments cc, query
+			WHERE cc.search_vector @@ query.tsq AND cc.deleted_at IS NULL AND cc.moderation_status = 'visible'
+			GROUP BY cc.complaint_id
+		)
+		SELECT c.*,
+			ts_rank_cd(c.search_vector, query.tsq) + COALESCE(comment_hits.rank, 0) * 0.5 AS rank,
+			CASE WHEN c.search_vector @@ query.tsq
+				THEN ts_headline('english', c.description, query.tsq, @opts) END AS highlight,
+			comment_hits.snippet AS comment_highlight,
+			COUNT(*) OVER () AS total
+		FROM (@filtered) AS c
+		CROSS JOIN query
+		LEFT JOIN comment_hits ON comment_hits.complaint_id = c.id
+		WHERE c.search_vector @@ query.tsq OR comment_hits.complaint_id IS NOT NULL
+		ORDER BY rank DESC, c.id DESC
+		LIMIT @limit OFFSET @offset`,
+		map[string]interface{}{
+			"q": q, "opts": headlineOptions, "filtered": filtered,
+			"limit": limit, "offset": offset,
+		}).Scan(&results).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var total int64
+	if len(results) > 0 {
+		total = results[0].Total
+	}
+	if results == nil {
+		results = []SearchResult{}
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results, "total": total, "limit": limit, "offset": offset})
+}
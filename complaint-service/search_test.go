@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSearchComplaintsHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		found       bool
+		wantCode    int
+		wantConfigs []string // tsquery configs sent
+		wantTotal   int64
+	}{
+		{name: "both configs by default", query: "?q=sadak+pothole", found: true, wantCode: http.StatusOK, wantConfigs: []string{"english", "simple"}, wantTotal: 12},
+		{name: "english only", query: "?q=potholes&lang=english", wantCode: http.StatusOK, wantConfigs: []string{"english"}},
+		{name: "simple only", query: "?q=kuppai&lang=simple", wantCode: http.StatusOK, wantConfigs: []string{"simple"}},
+		{name: "negative offset", query: "?q=pothole&offset=-5", wantCode: http.StatusOK, wantConfigs: []string{"english", "simple"}},
+		{name: "blank query", query: "?q=+++", wantCode: http.StatusBadRequest},
+		{name: "unknown language", query: "?q=pothole&lang=hindi", wantCode: http.StatusBadRequest},
+		{name: "bad filter", query: "?q=pothole&created_from=yesterday", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			if tt.found {
+				stub.returns("comment_hits", []string{"id", "description", "rank", "highlight", "total"},
+					[]driver.Value{int64(4), "Big pothole", 0.8, "Big <mark>pothole</mark>", int64(12)})
+			}
+			c, w := newTestContext(http.MethodGet, "/complaints/search"+tt.query, "", nil)
+
+			searchComplaintsHandler(c)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			for _, config := range []string{"english", "simple"} {
+				want := false
+				for _, sent := range tt.wantConfigs {
+					want = want || sent == config
+				}
+				if sent := stub.ran("websearch_to_tsquery('" + config + "'"); sent != want {
+					t.Errorf("%s tsquery sent = %v, want %v", config, sent, want)
+				}
+			}
+			var body struct {
+				Results []SearchResult `json:"results"`
+				Total   int64          `json:"total"`
+				Offset  int            `json:"offset"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Results == nil || body.Total != tt.wantTotal || body.Offset < 0 {
+				t.Errorf("results %v, total %d, offset %d", body.Results, body.Total, body.Offset)
+			}
+			if tt.found && body.Results[0].Highlight != "Big <mark>pothole</mark>" {
+				t.Errorf("highlight = %q", body.Results[0].Highlight)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ── SLA Policies ────────────────────────────────────────────────────────────
+//
+// A policy applies to a government and optionally narrows to a department
+// and/or category. The most specific matching policy wins:
+//   department + category → department → category → government default.
+
+type SLAPolicy struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	GovernmentID       uint      `gorm:"index;not null" json:"government_id"`
+	DepartmentID       *uint     `json:"department_id,omitempty"`
+	Category           string    `gorm:"not null;default:''" json:"category,omitempty"`
+	AcknowledgeMinutes int       `gorm:"not null" json:"acknowledge_minutes"`
+	ResolveMinutes     int       `gorm:"not null" json:"resolve_minutes"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// openStatuses still count against the resolve deadline.
+var openStatuses = []string{StatusPending, StatusAcknowledged, StatusInProgress, StatusPendingVerification, StatusReopened}
+
+func findSLAPolicy(tx *gorm.DB, govID uint, deptID *uint, category string) *SLAPolicy {
+	var policies []SLAPolicy
+	query := tx.Where("government_id = ?", govID).
+		Where("category = '' OR LOWER(category) = LOWER(?)", category)
+	if deptID != nil {
+		query = query.Where("department_id IS NULL OR department_id = ?", *deptID)
+	} else {
+		query = query.Where("department_id IS NULL")
+	}
+	query.Order("(department_id IS NOT NULL) DESC, (category <> '') DESC").Limit(1).Find(&policies)
+	if len(policies) == 0 {
+		return nil
+	}
+	return &policies[0]
+}
+
+// stampSLA sets due dates from the matching policy, counting from `from`.
+// The acknowledge deadline is only (re)stamped while the complaint is still
+// pending. Without a matching policy the deadlines are cleared. Breach
+// markers are history and are never touched, so a reassignment cannot hide
+// a breach that already happened.
+func stampSLA(tx *gorm.DB, complaint *Complaint, from time.Time) {
+	policy := findSLAPolicy(tx, complaint.GovernmentID, complaint.DepartmentID, complaint.Category)
+
+	complaint.SLAPolicyID = nil
+	if complaint.Status == "" || complaint.Status == StatusPending {
+		complaint.AcknowledgeDueAt = nil
+	}
+	complaint.ResolveDueAt = nil
+	if policy == nil {
+		return
+	}
+
+	complaint.SLAPolicyID = &policy.ID
+	if complaint.Status == "" || complaint.Status == StatusPending {
+		ackDue := from.Add(time.Duration(policy.AcknowledgeMinutes) * time.Minute)
+		complaint.AcknowledgeDueAt = &ackDue
+	}
+	resolveDue := from.Add(time.Duration(policy.ResolveMinutes) * time.Minute)
+	complaint.ResolveDueAt = &resolveDue
+}
+
+// slaColumns is what stampSLA changed, for a targeted complaint update.
+func slaColumns(complaint *Complaint) map[string]interface{} {
+	return map[string]interface{}{
+		"sla_policy_id":      complaint.SLAPolicyID,
+		"acknowledge_due_at": complaint.AcknowledgeDueAt,
+		"resolve_due_at":     complaint.ResolveDueAt,
+	}
+}
+
+// applySLAFilter narrows a complaints query by SLA state:
+// breached | due_soon | on_track.
+func applySLAFilter(query *gorm.DB, sla string) (*gorm.DB, error) {
+	now := time.Now()
+	soon := now.Add(slaDueSoonWindow())
+	switch sla {
+	case "":
+		return query, nil
+	case "breached":
+		return query.Where("acknowledge_breached_at IS NOT NULL OR resolve_breached_at IS NOT NULL"), nil
+	case "due_soon":
+		return query.
+			Where("acknowledge_breached_at IS NULL AND resolve_breached_at IS NULL").
+			Where("(status = ? AND acknowledge_due_at BETWEEN ? AND ?) OR (status IN ? AND resolve_due_at BETWEEN ? AND ?)",
+				StatusPending, now, soon, openStatuses, now, soon), nil
+	case "on_track":
+		return query.
+			Where("acknowledge_breached_at IS NULL AND resolve_breached_at IS NULL").
+			Where("resolve_due_at IS NOT NULL"), nil
+	}
+	return nil, fmt.Errorf("invalid sla filter %q (breached | due_soon | on_track)", sla)
+}
+
+func slaDueSoonWindow() time.Duration {
+	return time.Duration(envInt("SLA_DUE_SOON_HOURS", 24)) * time.Hour
+}
+
+// ── SLA Sweeper ─────────────────────────────────────────────────────────────
+
+// sweepSLABreaches marks complaints whose deadlines passed without the
+// required status change.
+func sweepSLABreaches() {
+	now := time.Now()
+	ack := db.Model(&Complaint{}).
+		Where("acknowledge_breached_at IS NULL AND acknowledge_due_at < ? AND status = ?", now, StatusPending).
+		Update("acknowledge_breached_at", now)
+	resolve := db.Model(&Complaint{}).
+		Where("resolve_breached_at IS NULL AND resolve_due_at < ? AND status IN ?", now, openStatuses).
+		Update("resolve_breached_at", now)
+	if ack.Error != nil || resolve.Error != nil {
+		log.Printf("[complaint-service] SLA sweep failed: ack=%v resolve=%v", ack.Error, resolve.Error)
+		return
+	}
+	if ack.RowsAffected > 0 || resolve.RowsAffected > 0 {
+		log.Printf("[complaint-service] SLA sweep: %d acknowledge, %d resolve breaches", ack.RowsAffected, resolve.RowsAffected)
+	}
+}
+
+func runSLASweeper() {
+	ticker := time.NewTicker(envDuration("SLA_SWEEP_INTERVAL", time.Minute))
+	defer ticker.Stop()
+	for range ticker.C {
+		sweepSLABreaches()
+	}
+}
+
+// ── SLA Policy Handlers ─────────────────────────────────────────────────────
+
+func listSLAPoliciesHandler(c *gin.Context) {
+	var policies []SLAPolicy
+	query := db
+	if getAdminRole(c) != "super_admin" {
+		query = query.Where("government_id = ?", getGovID(c))
+	} else if govID := c.Query("government_id"); govID != "" {
+		query = query.Where("government_id = ?", govID)
+	}
+	query.Order("government_id, department_id NULLS FIRST, category").Find(&policies)
+	c.JSON(http.StatusOK, policies)
+}
+
+func validateSLAPolicy(p *SLAPolicy) error {
+	if p.GovernmentID == 0 {
+		return fmt.Errorf("government_id is required")
+	}
+	if p.AcknowledgeMinutes <= 0 || p.ResolveMinutes <= 0 {
+		return fmt.Errorf("acknowledge_minutes and resolve_minutes must be positive")
+	}
+	if p.AcknowledgeMinutes > p.ResolveMinutes {
+		return fmt.Errorf("acknowledge_minutes cannot exceed resolve_minutes")
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is the database rejecting a
+// duplicate key.
+func isUniqueViolation(err error) bool {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+func createSLAPolicyHandler(c *gin.Context) {
+	var policy SLAPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	policy.ID = 0
+	if policy.GovernmentID == 0 {
+		policy.GovernmentID = getGovID(c)
+	}
+	if !canManageGovernment(c, policy.GovernmentID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "government is outside your jurisdiction"})
+		return
+	}
+	if err := validateSLAPolicy(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := db.Create(&policy).Error; err != nil {
+		if isUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "a policy for this government/department/category already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, policy)
+}
+
+func updateSLAPolicyHandler(c *gin.Context) {
+	var policy SLAPolicy
+	if err := db.First(&policy, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sla policy not found"})
+		return
+	}
+	if !canManageGovernment(c, policy.GovernmentID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "government is outside your jurisdiction"})
+		return
+	}
+	var body struct {
+		AcknowledgeMinutes int `json:"acknowledge_minutes"`
+		ResolveMinutes     int `json:"resolve_minutes"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.AcknowledgeMinutes != 0 {
+		policy.AcknowledgeMinutes = body.AcknowledgeMinutes
+	}
+	if body.ResolveMinutes != 0 {
+		policy.ResolveMinutes = body.ResolveMinutes
+	}
+	if err := validateSLAPolicy(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := db.Save(&policy).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+func deleteSLAPolicyHandler(c *gin.Context) {
+	var policy SLAPolicy
+	if err := db.First(&policy, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sla policy not found"})
+		return
+	}
+	if !canManageGovernment(c, policy.GovernmentID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "government is outside your jurisdiction"})
+		return
+	}
+	db.Delete(&policy)
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// pgError looks like a driver error carrying a PostgreSQL SQLSTATE.
+type pgError struct{ Code string }
+
+func (e *pgError) Error() string { return "pq: " + e.Code }
+
+func TestFindSLAPolicy(t *testing.T) {
+	dept := uint(4)
+	tests := []struct {
+		name     string
+		deptID   *uint
+		stored   bool
+		wantDept string // department condition sent
+		wantID   uint
+	}{
+		{name: "department complaint", deptID: &dept, stored: true, wantDept: "department_id IS NULL OR department_id = ", wantID: 9},
+		{name: "no department", stored: true, wantDept: "department_id IS NULL", wantID: 9},
+		{name: "no policy", deptID: &dept, wantDept: "department_id IS NULL OR department_id = "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			if tt.stored {
+				stub.returns(`FROM "sla_policies"`,
+					[]string{"id", "government_id", "department_id", "category", "acknowledge_minutes", "resolve_minutes"},
+					[]driver.Value{int64(9), int64(2), int64(4), "Roads", int64(60), int64(1440)})
+			}
+			policy := findSLAPolicy(db, 2, tt.deptID, "roads")
+			var gotID uint
+			if policy != nil {
+				gotID = policy.ID
+			}
+			if gotID != tt.wantID {
+				t.Errorf("policy = %d, want %d", gotID, tt.wantID)
+			}
+			if !stub.ran(tt.wantDept) {
+				t.Errorf("department condition %q not sent", tt.wantDept)
+			}
+			// department + category → department → category → default
+			if !stub.ran(`ORDER BY (department_id IS NOT NULL) DESC, (category <> '') DESC`) {
+				t.Error("most specific policy is not preferred")
+			}
+			if !stub.ran("LOWER(category) = LOWER(") {
+				t.Error("category is not matched case-insensitively")
+			}
+		})
+	}
+}
+
+func TestStampSLA(t *testing.T) {
+	from := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	earlier := from.Add(-time.Hour)
+	tests := []struct {
+		name        string
+		status      string
+		policy      bool
+		wantAck     *time.Time
+		wantResolve *time.Time
+	}{
+		{name: "new complaint", status: "", policy: true, wantAck: ptrTime(from.Add(time.Hour)), wantResolve: ptrTime(from.Add(24 * time.Hour))},
+		{name: "pending", status: StatusPending, policy: true, wantAck: ptrTime(from.Add(time.Hour)), wantResolve: ptrTime(from.Add(24 * time.Hour))},
+		{name: "acknowledged keeps its ack deadline", status: StatusInProgress, policy: true, wantAck: &earlier, wantResolve: ptrTime(from.Add(24 * time.Hour))},
+		{name: "no policy clears", status: StatusPending},
+		{name: "no policy keeps a past ack deadline", status: StatusInProgress, wantAck: &earlier},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			if tt.policy {
+				stub.returns(`FROM "sla_policies"`,
+					[]string{"id", "government_id", "acknowledge_minutes", "resolve_minutes"},
+					[]driver.Value{int64(9), int64(2), int64(60), int64(1440)})
+			}
+			complaint := Complaint{GovernmentID: 2, Status: tt.status, AcknowledgeDueAt: &earlier, ResolveDueAt: &earlier}
+			stampSLA(db, &complaint, from)
+			if !sameTime(complaint.AcknowledgeDueAt, tt.wantAck) {
+				t.Errorf("acknowledge_due_at = %v, want %v", complaint.AcknowledgeDueAt, tt.wantAck)
+			}
+			if !sameTime(complaint.ResolveDueAt, tt.wantResolve) {
+				t.Errorf("resolve_due_at = %v, want %v", complaint.ResolveDueAt, tt.wantResolve)
+			}
+			if (complaint.SLAPolicyID != nil) != tt.policy {
+				t.Errorf("sla_policy_id = %v, want set %v", complaint.SLAPolicyID, tt.policy)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func TestValidateSLAPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  SLAPolicy
+		wantErr bool
+	}{
+		{name: "valid", policy: SLAPolicy{GovernmentID: 2, AcknowledgeMinutes: 60, ResolveMinutes: 1440}},
+		{name: "equal deadlines", policy: SLAPolicy{GovernmentID: 2, AcknowledgeMinutes: 60, ResolveMinutes: 60}},
+		{name: "no government", policy: SLAPolicy{AcknowledgeMinutes: 60, ResolveMinutes: 1440}, wantErr: true},
+		{name: "zero acknowledge", policy: SLAPolicy{GovernmentID: 2, ResolveMinutes: 1440}, wantErr: true},
+		{name: "negative resolve", policy: SLAPolicy{GovernmentID: 2, AcknowledgeMinutes: 60, ResolveMinutes: -1}, wantErr: true},
+		{name: "acknowledge after resolve", policy: SLAPolicy{GovernmentID: 2, AcknowledgeMinutes: 120, ResolveMinutes: 60}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSLAPolicy(&tt.policy); (err != nil) != tt.wantErr {
+				t.Errorf("validateSLAPolicy() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateSLAPolicyHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		insert   error
+		wantCode int
+	}{
+		{name: "created", wantCode: http.StatusCreated},
+		{name: "duplicate", insert: &pgError{Code: "23505"}, wantCode: http.StatusConflict},
+		{name: "database down", insert: errors.New("connection refused"), wantCode: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.returns(`INSERT INTO "sla_policies"`, []string{"id"}, []driver.Value{int64(9)})
+			if tt.insert != nil {
+				stub.fails(`INSERT INTO "sla_policies"`, tt.insert)
+			}
+			c, w := newTestContext(http.MethodPost, "/complaints/sla-policies", `{"category":"Roads","acknowledge_minutes":60,"resolve_minutes":1440}`, superAdminClaims)
+
+			createSLAPolicyHandler(c)
+			if w.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+}
+
+func TestSLADueSoonWindow(t *testing.T) {
+	for value, want := range map[string]time.Duration{"": 24 * time.Hour, "6": 6 * time.Hour, "0": 24 * time.Hour, "soon": 24 * time.Hour} {
+		t.Setenv("SLA_DUE_SOON_HOURS", value)
+		if got := slaDueSoonWindow(); got != want {
+			t.Errorf("SLA_DUE_SOON_HOURS=%q: window = %v, want %v", value, got, want)
+		}
+	}
+}
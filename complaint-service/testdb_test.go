@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ── Test Database ───────────────────────────────────────────────────────────
+//
+// Most logic here runs inside a *gorm.DB but only needs a few rows back.
+// stubDB gives it a database/sql driver with no server behind it: every
+// query returns the rows registered for the first matching SQL fragment
+// (none by default), every statement affects execRows rows, and statements
+// matching a failing fragment return its error.
+
+type stubRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+type stubDriver struct {
+	mu       sync.Mutex
+	results  map[string]stubRows
+	failures map[string]error
+	execRows int64
+	executed []string
+}
+
+var (
+	stubDrivers   = map[string]*stubDriver{}
+	stubDriversMu sync.Mutex
+)
+
+func init() {
+	sql.Register("stub", stubConnector{})
+	gin.SetMode(gin.TestMode)
+}
+
+// newStubDB opens a gorm handle on a fresh stub and installs it as the
+// package db for the duration of the test.
+func newStubDB(t *testing.T) *stubDriver {
+	t.Helper()
+	stub := &stubDriver{results: map[string]stubRows{}, failures: map[string]error{}, execRows: 1}
+	stubDriversMu.Lock()
+	stubDrivers[t.Name()] = stub
+	stubDriversMu.Unlock()
+
+	sqlDB, err := sql.Open("stub", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := db
+	db = gdb
+	t.Cleanup(func() {
+		db = previous
+		sqlDB.Close()
+		stubDriversMu.Lock()
+		delete(stubDrivers, t.Name())
+		stubDriversMu.Unlock()
+	})
+	return stub
+}
+
+// returns registers the rows for queries containing fragment.
+func (s *stubDriver) returns(fragment string, columns []string, values ...[]driver.Value) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[fragment] = stubRows{columns: columns, values: values}
+}
+
+// fails makes statements containing fragment return err.
+func (s *stubDriver) fails(fragment string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[fragment] = err
+}
+
+func (s *stubDriver) rowsFor(query string) (stubRows, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.executed = append(s.executed, query)
+	for fragment, err := range s.failures {
+		if strings.Contains(query, fragment) {
+			return stubRows{}, err
+		}
+	}
+	for fragment, rows := range s.results {
+		if strings.Contains(query, fragment) {
+			return rows, nil
+		}
+	}
+	return stubRows{}, nil
+}
+
+// ran reports whether any statement containing fragment was sent.
+func (s *stubDriver) ran(fragment string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, q := range s.executed {
+		if strings.Contains(q, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// ── database/sql plumbing ──
+
+type stubConnector struct{}
+
+func (stubConnector) Open(name string) (driver.Conn, error) {
+	stubDriversMu.Lock()
+	defer stubDriversMu.Unlock()
+	return &stubConn{stub: stubDrivers[name]}, nil
+}
+
+type stubConn struct{ stub *stubDriver }
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) { return &stubStmt{c, query}, nil }
+func (c *stubConn) Close() error                              { return nil }
+func (c *stubConn) Begin() (driver.Tx, error)                 { return stubTx{}, nil }
+
+func (c *stubConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return stubTx{}, nil
+}
+
+func (c *stubConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.stub.rowsFor(query)
+	if err != nil {
+		return nil, err
+	}
+	return &stubResultRows{rows: rows}, nil
+}
+
+func (c *stubConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.stub.rowsFor(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(c.stub.execRows), nil
+}
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+type stubStmt struct {
+	conn  *stubConn
+	query string
+}
+
+func (s *stubStmt) Close() error  { return nil }
+func (s *stubStmt) NumInput() int { return -1 }
+
+func (s *stubStmt) Exec([]driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, nil)
+}
+
+func (s *stubStmt) Query([]driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, nil)
+}
+
+type stubResultRows struct {
+	rows stubRows
+	next int
+}
+
+func (r *stubResultRows) Columns() []string { return r.rows.columns }
+func (r *stubResultRows) Close() error      { return nil }
+
+func (r *stubResultRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows.values) {
+		return io.EOF
+	}
+	copy(dest, r.rows.values[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ── Votes ───────────────────────────────────────────────────────────────────
+//
+// One row per (complaint, user) with value +1 or -1. Casting, switching and
+// retracting a vote update the complaint counters in the same transaction.
+
+type ComplaintVote struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ComplaintID uint      `gorm:"not null;uniqueIndex:idx_vote_unique" json:"complaint_id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_vote_unique" json:"user_id"`
+	Value       int       `gorm:"not null" json:"value"` // +1 upvote | -1 downvote
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+var errVoteOnMerged = errors.New("complaint was merged, vote on the parent complaint")
+
+// migrateLegacyVotes folds the old complaint_upvotes/complaint_downvotes
+// tables into complaint_votes. A user who had both keeps the upvote.
+func migrateLegacyVotes() {
+	if !db.Migrator().HasTable("complaint_upvotes") {
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		steps := []string{
+			`INSERT INTO complaint_votes (complaint_id, user_id, value, created_at, updated_at)
+				SELECT complaint_id, user_id, 1, NOW(), NOW() FROM complaint_upvotes
+				ON CONFLICT DO NOTHING`,
+			`INSERT INTO complaint_votes (complaint_id, user_id, value, created_at, updated_at)
+				SELECT complaint_id, user_id, -1, NOW(), NOW() FROM complaint_downvotes
+				ON CONFLICT DO NOTHING`,
+			`UPDATE complaints SET
+				upvotes = (SELECT COUNT(*) FROM complaint_votes v WHERE v.complaint_id = complaints.id AND v.value = 1),
+				downvotes = (SELECT COUNT(*) FROM complaint_votes v WHERE v.complaint_id = complaints.id AND v.value = -1)`,
+			`DROP TABLE IF EXISTS complaint_upvotes`,
+			`DROP TABLE IF EXISTS complaint_downvotes`,
+		}
+		for _, sql := range steps {
+			if err := tx.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[complaint-service] Legacy vote migration failed: %v", err)
+		return
+	}
+	log.Println("[complaint-service] Migrated legacy upvotes/downvotes into complaint_votes")
+}
+
+// castVote sets (value ±1) or retracts (value 0) the user's vote and keeps
+// the complaint counters in step.
+func castVote(tx *gorm.DB, complaintID, userID uint, value int) (*Complaint, error) {
+	var complaint Complaint
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&complaint, complaintID).Error; err != nil {
+		return nil, err
+	}
+	if complaint.MergedIntoID != nil {
+		return &complaint, errVoteOnMerged
+	}
+
+	var existing ComplaintVote
+	old := 0
+	if tx.Where("complaint_id = ? AND user_id = ?", complaintID, userID).Limit(1).Find(&existing).RowsAffected > 0 {
+		old = existing.Value
+	}
+	if old == value {
+		return &complaint, nil
+	}
+
+	switch {
+	case value == 0:
+		if err := tx.Delete(&existing).Error; err != nil {
+			return nil, err
+		}
+	case old == 0:
+		if err := tx.Create(&ComplaintVote{ComplaintID: complaintID, UserID: userID, Value: value}).Error; err != nil {
+			return nil, err
+		}
+	default:
+		if err := tx.Model(&existing).Update("value", value).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	up := boolToInt(value == 1) - boolToInt(old == 1)
+	down := boolToInt(value == -1) - boolToInt(old == -1)
+	err := tx.Model(&complaint).Updates(map[string]interface{}{
+		"upvotes":   gorm.Expr("upvotes + ?", up),
+		"downvotes": gorm.Expr("downvotes + ?", down),
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	complaint.Upvotes += up
+	complaint.Downvotes += down
+	return &complaint, nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// recountVotes recomputes the denormalized counters from complaint_votes.
+func recountVotes(tx *gorm.DB, complaintID uint) error {
+	return tx.Exec(`
+		UPDATE complaints SET
+			upvotes = (SELECT COUNT(*) FROM complaint_votes WHERE complaint_id = ? AND value = 1),
+			downvotes = (SELECT COUNT(*) FROM complaint_votes WHERE complaint_id = ? AND value = -1)
+		WHERE id = ?`, complaintID, complaintID, complaintID).Error
+}
+
+// ── Vote Handlers ───────────────────────────────────────────────────────────
+
+func respondVote(c *gin.Context, complaintID uint, value int) {
+	var complaint *Complaint
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		complaint, err = castVote(tx, complaintID, getUserID(c), value)
+		return err
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "complaint not found"})
+		return
+	case errors.Is(err, errVoteOnMerged):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "merged_into_id": complaint.MergedIntoID})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"complaint_id":   complaint.ID,
+		"value":          value,
+		"upvotes":        complaint.Upvotes,
+		"downvotes":      complaint.Downvotes,
+		"priority_score": complaint.PriorityScore(),
+	})
+}
+
+func complaintIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid complaint id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// PUT /complaints/:id/vote {"value": 1 | -1} — cast or switch
+func putVoteHandler(c *gin.Context) {
+	complaintID, ok := complaintIDParam(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Value int `json:"value" binding:"required,oneof=1 -1"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "value must be 1 or -1"})
+		return
+	}
+	respondVote(c, complaintID, body.Value)
+}
+
+// DELETE /complaints/:id/vote — retract
+func deleteVoteHandler(c *gin.Context) {
+	complaintID, ok := complaintIDParam(c)
+	if !ok {
+		return
+	}
+	respondVote(c, complaintID, 0)
+}
+
+// GET /complaints/:id/vote — the caller's current vote (0 if none)
+func getVoteHandler(c *gin.Context) {
+	complaintID, ok := complaintIDParam(c)
+	if !ok {
+		return
+	}
+	var vote ComplaintVote
+	db.Where("complaint_id = ? AND user_id = ?", complaintID, getUserID(c)).Limit(1).Find(&vote)
+	c.JSON(http.StatusOK, gin.H{"complaint_id": complaintID, "value": vote.Value})
+}
+
+// Deprecated: POST /complaints/:id/upvote and /downvote, kept for older clients.
+func upvoteHandler(c *gin.Context) {
+	if complaintID, ok := complaintIDParam(c); ok {
+		respondVote(c, complaintID, 1)
+	}
+}
+
+func downvoteHandler(c *gin.Context) {
+	if complaintID, ok := complaintIDParam(c); ok {
+		respondVote(c, complaintID, -1)
+	}
+}
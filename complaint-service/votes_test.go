@@ -0,0 +1,70 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestCastVote(t *testing.T) {
+	tests := []struct {
+		name       string
+		old, value int
+		wantUp     int
+		wantDown   int
+		wantSQL    string // the complaint_votes statement, empty for a no-op
+	}{
+		{name: "upvote", old: 0, value: 1, wantUp: 5, wantDown: 2, wantSQL: `INSERT INTO "complaint_votes"`},
+		{name: "downvote", old: 0, value: -1, wantUp: 4, wantDown: 3, wantSQL: `INSERT INTO "complaint_votes"`},
+		{name: "switch to down", old: 1, value: -1, wantUp: 3, wantDown: 3, wantSQL: `UPDATE "complaint_votes"`},
+		{name: "switch to up", old: -1, value: 1, wantUp: 5, wantDown: 1, wantSQL: `UPDATE "complaint_votes"`},
+		{name: "retract upvote", old: 1, value: 0, wantUp: 3, wantDown: 2, wantSQL: `DELETE FROM "complaint_votes"`},
+		{name: "retract downvote", old: -1, value: 0, wantUp: 4, wantDown: 1, wantSQL: `DELETE FROM "complaint_votes"`},
+		{name: "upvote again", old: 1, value: 1, wantUp: 4, wantDown: 2},
+		{name: "retract nothing", old: 0, value: 0, wantUp: 4, wantDown: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubDB(t)
+			stub.returns(`FROM "complaints"`, []string{"id", "upvotes", "downvotes"}, []driver.Value{int64(1), int64(4), int64(2)})
+			if tt.old != 0 {
+				stub.returns(`FROM "complaint_votes"`, []string{"id", "complaint_id", "user_id", "value"},
+					[]driver.Value{int64(8), int64(1), int64(5), int64(tt.old)})
+			}
+			stub.returns(`INSERT INTO "complaint_votes"`, []string{"id"}, []driver.Value{int64(9)})
+
+			complaint, err := castVote(db, 1, 5, tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if complaint.Upvotes != tt.wantUp || complaint.Downvotes != tt.wantDown {
+				t.Errorf("votes = +%d -%d, want +%d -%d", complaint.Upvotes, complaint.Downvotes, tt.wantUp, tt.wantDown)
+			}
+			if tt.wantSQL == "" {
+				if stub.ran(`UPDATE "complaints"`) {
+					t.Error("counters written for a no-op")
+				}
+				return
+			}
+			if !stub.ran(tt.wantSQL) {
+				t.Errorf("%q not sent", tt.wantSQL)
+			}
+			// Counters move relative to the locked row, never as absolute values
+			if !stub.ran(`"upvotes"=upvotes + `) {
+				t.Error("counters not updated in place")
+			}
+		})
+	}
+}
+
+func TestCastVoteOnMerged(t *testing.T) {
+	stub := newStubDB(t)
+	stub.returns(`FROM "complaints"`, []string{"id", "merged_into_id"}, []driver.Value{int64(1), int64(2)})
+
+	if _, err := castVote(db, 1, 5, 1); !errors.Is(err, errVoteOnMerged) {
+		t.Fatalf("err = %v, want %v", err, errVoteOnMerged)
+	}
+	if stub.ran(`"complaint_votes"`) {
+		t.Error("vote recorded on a merged complaint")
+	}
+}